@@ -2,6 +2,7 @@ package main
 
 import (
 	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/github"
 	"github.com/anchore/go-make/tasks/golint"
 	"github.com/anchore/go-make/tasks/gotest"
 	"github.com/anchore/go-make/tasks/release"
@@ -13,5 +14,6 @@ func main() {
 		gotest.Tasks(),
 		release.ChangelogTask(),
 		release.GhReleaseTask(),
+		github.SimulateTask(),
 	)
 }
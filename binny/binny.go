@@ -108,15 +108,24 @@ func Install(cmd string) string {
 	toolPath := ToolPath(cmd)
 	toolDir := filepath.Dir(toolPath)
 
-	out := bytes.Buffer{}
+	alreadyInstalled := false
+	var out []string
 	lang.Return(run.Command(binnyPath, run.Options(cfg...), run.Args("install", cmd),
 		run.Env("BINNY_LOG_LEVEL", "info"),
 		run.Env("BINNY_ROOT", toolDir),
 		run.Quiet(),
-		run.Stderr(&out),
+		run.OnLine(func(stream, line string) {
+			if stream != "stderr" {
+				return
+			}
+			out = append(out, line)
+			if strings.Contains(line, "already installed") {
+				alreadyInstalled = true
+			}
+		}),
 	))
 
-	if !strings.Contains(out.String(), "already installed") {
+	if !alreadyInstalled {
 		// check if binny has given us an executable without .exe on windows and copy it, if so
 		nonExe := filepath.Join(toolDir, cmd)
 		if config.Windows && nonExe != toolPath && file.Exists(nonExe) {
@@ -127,7 +136,7 @@ func Install(cmd string) string {
 			}))
 		}
 		log.Info("binny installed: %v at %v", cmd, toolPath)
-		log.Debug("    └─ output: %v", out.String())
+		log.Debug("    └─ output: %v", strings.Join(out, "\n"))
 	}
 
 	return toolPath
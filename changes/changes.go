@@ -0,0 +1,178 @@
+package changes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+)
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// Files returns the files changed since the merge base with Base, relative to the git root, including uncommitted
+// and untracked files; the result is computed once and cached
+var Files = sync.OnceValues(func() ([]string, error) {
+	return changedFiles(Base())
+})
+
+// Match indicates any changed file matches one of the provided globs, relative to the git root;
+// if changed files cannot be determined, this returns true so nothing is skipped by mistake
+func Match(globs ...string) bool {
+	files, err := Files()
+	if err != nil {
+		log.Debug("unable to determine changed files, assuming all changed: %v", err)
+		return true
+	}
+	for _, f := range files {
+		for _, glob := range globs {
+			if lang.Return(doublestar.Match(glob, f)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Base returns the revision to compare changes against, in order of preference: the CHANGES_BASE environment
+// variable, the base commit from a GitHub pull request, merge group or push event payload, GITHUB_BASE_REF
+// on the origin remote, and finally the default branch of the origin remote
+func Base() string {
+	if base := config.Env("CHANGES_BASE", ""); base != "" {
+		return base
+	}
+	if base := eventBase(os.Getenv("GITHUB_EVENT_PATH")); base != "" {
+		return base
+	}
+	if baseRef := os.Getenv("GITHUB_BASE_REF"); baseRef != "" {
+		return "origin/" + baseRef
+	}
+	if branch, err := git.DefaultBranch("origin"); err == nil {
+		return "origin/" + branch
+	}
+	return "origin/main"
+}
+
+// eventBase reads the base commit from a GitHub event payload, only the few fields needed here are read since
+// the github package depends on this module's task definitions
+func eventBase(eventPath string) string {
+	if eventPath == "" {
+		return ""
+	}
+	contents, err := os.ReadFile(eventPath)
+	if err != nil {
+		log.Debug("unable to read event file %v: %v", eventPath, err)
+		return ""
+	}
+	var event struct {
+		Before      string `json:"before"`
+		Created     bool   `json:"created"`
+		PullRequest struct {
+			Base struct {
+				SHA string `json:"sha"`
+			} `json:"base"`
+		} `json:"pull_request"`
+		MergeGroup struct {
+			BaseSHA string `json:"base_sha"`
+		} `json:"merge_group"`
+	}
+	if err = json.Unmarshal(contents, &event); err != nil {
+		log.Debug("unable to parse event file %v: %v", eventPath, err)
+		return ""
+	}
+	switch {
+	case event.PullRequest.Base.SHA != "":
+		return event.PullRequest.Base.SHA
+	case event.MergeGroup.BaseSHA != "":
+		return event.MergeGroup.BaseSHA
+	case event.Before != "" && event.Before != zeroSHA && !event.Created:
+		return event.Before
+	}
+	return ""
+}
+
+func changedFiles(base string) ([]string, error) {
+	from, err := mergeBase(base)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find merge base with %v: %w", base, err)
+	}
+
+	changed, err := git.ChangedFiles(from, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine changes from %v: %w", base, err)
+	}
+	untracked, err := git.UntrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(changed, untracked...)
+	log.Debug("changed files since %v: %v", base, out)
+	return out, nil
+}
+
+// deepenBy is the number of commits a shallow clone is deepened by at a time, up to deepenTimes before all history
+// is fetched
+var (
+	deepenBy    = 50
+	deepenTimes = 10
+)
+
+// mergeBase returns the merge base of the base and HEAD; shallow clones, such as the default GitHub Actions checkout,
+// may not have the base or enough history to find it, so the base is fetched and history deepened until it is found
+func mergeBase(base string) (string, error) {
+	from, err := git.MergeBase(base, "HEAD")
+	if err == nil {
+		return from, nil
+	}
+	log.Debug("unable to find merge base with %v, fetching: %v", base, err)
+
+	refspec := base
+	if name, ok := strings.CutPrefix(base, "origin/"); ok {
+		refspec = "+refs/heads/" + name + ":refs/remotes/origin/" + name
+	}
+	fetch := func(args ...string) error {
+		_, err := run.Command("git", run.Args("fetch", "--no-tags"), run.Args(args...), run.Args("origin", refspec), run.Quiet())
+		return err
+	}
+
+	shallow, err := git.IsShallow()
+	if err != nil {
+		return "", err
+	}
+	if !shallow {
+		if err = fetch(); err != nil {
+			return "", err
+		}
+		return git.MergeBase(base, "HEAD")
+	}
+
+	if err = fetch("--depth=" + strconv.Itoa(deepenBy)); err != nil {
+		return "", err
+	}
+	for range deepenTimes {
+		if from, err = git.MergeBase(base, "HEAD"); err == nil {
+			return from, nil
+		}
+		if err = fetch("--deepen=" + strconv.Itoa(deepenBy)); err != nil {
+			return "", err
+		}
+	}
+	if from, err = git.MergeBase(base, "HEAD"); err == nil {
+		return from, nil
+	}
+	log.Debug("no merge base with %v after deepening %d commits, fetching all history", base, deepenBy*deepenTimes)
+	if err = fetch("--unshallow"); err != nil {
+		return "", err
+	}
+	return git.MergeBase(base, "HEAD")
+}
@@ -0,0 +1,107 @@
+package changes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_changedFiles(t *testing.T) {
+	defer require.Test(t)
+
+	dir := require.GitRepo(t)
+	require.Git(t, dir, "checkout", "-b", "feature")
+	require.GitCommit(t, dir, "some change", map[string]string{
+		"docs/guide.md": "guide",
+		"run/run.go":    "package run",
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("modified"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "untracked.md"), []byte("new"), 0o600))
+
+	// file paths are relative to the root, regardless of the current directory
+	file.InDir(filepath.Join(dir, "docs"), func() {
+		files, err := changedFiles("main")
+		require.NoError(t, err)
+		require.EqualElements(t, []string{"README.md", "docs/guide.md", "docs/untracked.md", "run/run.go"}, files)
+	})
+}
+
+func Test_changedFilesShallowClone(t *testing.T) {
+	defer require.Test(t)
+	require.SetAndRestore(t, &deepenBy, 2)
+
+	origin := require.GitRepo(t)
+	require.Git(t, origin, "checkout", "-b", "feature")
+	for i := range 5 {
+		require.GitCommit(t, origin, "feature change", map[string]string{fmt.Sprintf("feature/%d.go", i): "package feature"})
+	}
+	// changes on the base branch after the feature branched are not feature changes
+	require.Git(t, origin, "checkout", "main")
+	require.GitCommit(t, origin, "main change", map[string]string{"main.go": "package main"})
+
+	clone := t.TempDir()
+	require.Git(t, clone, "clone", "--depth=1", "--branch", "feature", "file://"+filepath.ToSlash(origin), ".")
+
+	file.InDir(clone, func() {
+		files, err := changedFiles("origin/main")
+		require.NoError(t, err)
+		require.EqualElements(t, []string{"feature/0.go", "feature/1.go", "feature/2.go", "feature/3.go", "feature/4.go"}, files)
+	})
+}
+
+func Test_Match(t *testing.T) {
+	require.SetAndRestore(t, &Files, func() ([]string, error) {
+		return []string{"docs/guide.md", "README.md"}, nil
+	})
+
+	require.True(t, Match("**/*.md"))
+	require.True(t, Match("**/*.go", "docs/**"))
+	require.True(t, !Match("**/*.go"))
+	require.True(t, !Match("docs/*.go", "*.yaml"))
+}
+
+func Test_eventBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		expected string
+	}{
+		{
+			name:     "pull request",
+			event:    `{"before":"abc","pull_request":{"base":{"ref":"main","sha":"base-sha"}}}`,
+			expected: "base-sha",
+		},
+		{
+			name:     "merge group",
+			event:    `{"merge_group":{"base_sha":"merge-base-sha"}}`,
+			expected: "merge-base-sha",
+		},
+		{
+			name:     "push",
+			event:    `{"before":"before-sha","after":"after-sha"}`,
+			expected: "before-sha",
+		},
+		{
+			name:     "new branch push",
+			event:    `{"before":"0000000000000000000000000000000000000000","created":true}`,
+			expected: "",
+		},
+		{
+			name:     "schedule",
+			event:    `{"schedule":"0 0 * * 0"}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventFile := filepath.Join(t.TempDir(), "event.json")
+			require.NoError(t, os.WriteFile(eventFile, []byte(tt.event), 0o600))
+			require.Equal(t, tt.expected, eventBase(eventFile))
+		})
+	}
+}
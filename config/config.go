@@ -11,10 +11,17 @@ var (
 	// ToolDir is the template to find the root tool directory
 	ToolDir = "{{RootDir}}/.tool"
 	// RootDir is the template to find the root directory when executing
-	RootDir = "{{GitRoot}}"
+	RootDir = "{{ProjectRoot}}"
+	// RootMarker optionally names a file or directory, e.g. .make or go.mod, marking the project root within the
+	// repository; the nearest parent containing it is used as ProjectRoot instead of the git root
+	RootMarker = ""
 	// TmpDir is the template to find the an alternate TempDir, if empty defaults to system temp dir
 	TmpDir = ""
 
+	// ContainerCLI is the docker-compatible CLI used by the docker package, e.g. podman; if empty, docker or podman
+	// is detected
+	ContainerCLI = ""
+
 	// OS is the OS name to request for commands that require OS name
 	OS = runtime.GOOS
 	// Arch is the architecture to request for commands that require architecture
@@ -31,13 +38,19 @@ var (
 	Windows = runtime.GOOS == "windows"
 	// Cleanup whether to remove temporary files and downloads
 	Cleanup = true
+	// ChangedOnly skips tasks and tests unaffected by the files changed since the merge base, see the changes package;
+	// opt-in with CHANGED_ONLY=true, e.g. for pull request builds
+	ChangedOnly = false
 )
 
 func init() {
+	RootMarker = Env("ROOT_MARKER", RootMarker)
+	ContainerCLI = Env("CONTAINER_CLI", ContainerCLI)
 	Trace, _ = strconv.ParseBool(Env("TRACE", "false"))
 	Debug, _ = strconv.ParseBool(Env("DEBUG", strconv.FormatBool(runnerDebug() || Trace)))
 	CI, _ = strconv.ParseBool(Env("CI", "false"))
 	Cleanup = !Debug && !CI
+	ChangedOnly, _ = strconv.ParseBool(Env("CHANGED_ONLY", "false"))
 }
 
 func runnerDebug() bool {
@@ -20,11 +20,14 @@ func OnExit(fn func()) {
 	onExit = append(onExit, fn)
 }
 
+// DoExit runs the registered OnExit functions in reverse order; each is only run once, even if DoExit is called again
 func DoExit() {
 	onExitLock.Lock()
-	defer onExitLock.Unlock()
+	fns := onExit
+	onExit = nil
+	onExitLock.Unlock()
 	// reverse order, like defer
-	for i := len(onExit) - 1; i >= 0; i-- {
-		onExit[i]()
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
 	}
 }
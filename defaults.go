@@ -5,8 +5,10 @@ import (
 
 	"github.com/anchore/go-make/binny"
 	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/gomod"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/template"
+	"github.com/anchore/go-make/version"
 )
 
 //go:embed .binny.yaml
@@ -14,9 +16,12 @@ var defaultBinnyConfig embed.FS
 
 func init() {
 	binny.DefaultConfig(lang.Return(defaultBinnyConfig.Open(".binny.yaml")))
+	template.Globals["ModuleRoot"] = gomod.Root
+	template.Globals["Version"] = version.Current
 }
 
-// RootDir returns the root directory of the repository; typically the repository root, located by the .git directory
+// RootDir returns the root directory of the project; typically the repository root, located by the .git entry, or the
+// nearest directory containing config.RootMarker when set
 func RootDir() string {
 	return template.Render(config.RootDir)
 }
@@ -0,0 +1,254 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/template"
+)
+
+// RepoMountPath is the path the repository is mounted to with MountRepo
+const RepoMountPath = "/workspace"
+
+// Config holds the settings used to run containers and build images
+type Config struct {
+	Mounts     []Volume
+	Env        map[string]string
+	PassEnv    []string
+	User       string
+	Workdir    string
+	Platform   string
+	Entrypoint string
+	Command    []string
+	BuildArgs  map[string]string
+	Dockerfile string
+	Options    []run.Option
+}
+
+// Volume is a host path bind mounted into the container
+type Volume struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+type Option func(*Config)
+
+// CLI returns the container CLI to use: config.ContainerCLI if set, otherwise docker or podman, whichever is found first
+func CLI() string {
+	if config.ContainerCLI != "" {
+		return config.ContainerCLI
+	}
+	for _, cli := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(cli); err == nil {
+			return cli
+		}
+	}
+	panic(fmt.Errorf("no container CLI found, install docker or podman or set CONTAINER_CLI"))
+}
+
+// Run executes the configured Command in a new container from the image, removing the container afterward and
+// returning stdout
+func Run(image string, opts ...Option) string {
+	cfg := newConfig(opts...)
+	return lang.Return(run.Command(CLI(), run.Args(runArgs(image, cfg)...), run.Options(cfg.Options...)))
+}
+
+// Build builds an image tagged with tag from the contextDir, using contextDir/Dockerfile unless Dockerfile is specified
+func Build(tag, contextDir string, opts ...Option) {
+	cfg := newConfig(opts...)
+	lang.Return(run.Command(CLI(), run.Args(buildArgs(tag, contextDir, cfg)...), run.Options(cfg.Options...)))
+}
+
+// Mount bind mounts the host source path to the target path in the container
+func Mount(source, target string) Option {
+	return func(cfg *Config) {
+		cfg.Mounts = append(cfg.Mounts, Volume{Source: source, Target: target})
+	}
+}
+
+// MountReadOnly bind mounts the host source path to the target path in the container, read-only
+func MountReadOnly(source, target string) Option {
+	return func(cfg *Config) {
+		cfg.Mounts = append(cfg.Mounts, Volume{Source: source, Target: target, ReadOnly: true})
+	}
+}
+
+// MountRepo mounts the project RootDir to RepoMountPath and sets the working directory to the container path
+// matching the current directory
+func MountRepo() Option {
+	return func(cfg *Config) {
+		root := lang.Return(filepath.Abs(template.Render(config.RootDir)))
+		cfg.Mounts = append(cfg.Mounts, Volume{Source: root, Target: RepoMountPath})
+		cfg.Workdir = RepoMountPath
+		rel, err := filepath.Rel(root, file.Cwd())
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			cfg.Workdir = RepoMountPath + "/" + filepath.ToSlash(rel)
+		}
+	}
+}
+
+// Env sets an environment variable in the container
+func Env(key, value string) Option {
+	return func(cfg *Config) {
+		if cfg.Env == nil {
+			cfg.Env = map[string]string{}
+		}
+		cfg.Env[key] = value
+	}
+}
+
+// PassEnv passes the named environment variables from the current process through to the container, if set,
+// including variables not passed to commands by default, such as GOOS, see run.DefaultDropEnv
+func PassEnv(names ...string) Option {
+	return func(cfg *Config) {
+		cfg.PassEnv = append(cfg.PassEnv, names...)
+		// the CLI reads the values from its own environment, so it must inherit them
+		cfg.Options = append(cfg.Options, run.InheritEnv(names...))
+	}
+}
+
+// User runs the container process as the given user, e.g. "1000:1000"
+func User(user string) Option {
+	return func(cfg *Config) {
+		cfg.User = user
+	}
+}
+
+// CurrentUser runs the container process with the current user and group IDs, so files written to mounts are owned
+// by the invoking user; this has no effect on Windows
+func CurrentUser() Option {
+	return func(cfg *Config) {
+		if runtime.GOOS == "windows" {
+			return
+		}
+		cfg.User = strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+	}
+}
+
+// Workdir sets the working directory inside the container
+func Workdir(dir string) Option {
+	return func(cfg *Config) {
+		cfg.Workdir = dir
+	}
+}
+
+// Platform sets the platform to run or build, e.g. linux/arm64
+func Platform(platform string) Option {
+	return func(cfg *Config) {
+		cfg.Platform = platform
+	}
+}
+
+// Entrypoint overrides the image entrypoint
+func Entrypoint(entrypoint string) Option {
+	return func(cfg *Config) {
+		cfg.Entrypoint = entrypoint
+	}
+}
+
+// Command sets the command and arguments to run in the container
+func Command(args ...string) Option {
+	return func(cfg *Config) {
+		cfg.Command = append(cfg.Command, args...)
+	}
+}
+
+// BuildArg sets a --build-arg when building images
+func BuildArg(key, value string) Option {
+	return func(cfg *Config) {
+		if cfg.BuildArgs == nil {
+			cfg.BuildArgs = map[string]string{}
+		}
+		cfg.BuildArgs[key] = value
+	}
+}
+
+// Dockerfile specifies the Dockerfile to build
+func Dockerfile(path string) Option {
+	return func(cfg *Config) {
+		cfg.Dockerfile = path
+	}
+}
+
+// RunOptions adds run.Option(s) used when executing the container CLI, e.g. run.Quiet()
+func RunOptions(opts ...run.Option) Option {
+	return func(cfg *Config) {
+		cfg.Options = append(cfg.Options, opts...)
+	}
+}
+
+func newConfig(opts ...Option) Config {
+	cfg := Config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+func runArgs(image string, cfg Config) []string {
+	args := []string{"run", "--rm"}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+	if cfg.User != "" {
+		args = append(args, "--user", cfg.User)
+	}
+	for _, m := range cfg.Mounts {
+		v := m.Source + ":" + m.Target
+		if m.ReadOnly {
+			v += ":ro"
+		}
+		args = append(args, "-v", v)
+	}
+	for _, key := range sortedKeys(cfg.Env) {
+		args = append(args, "-e", key+"="+cfg.Env[key])
+	}
+	for _, name := range cfg.PassEnv {
+		if _, ok := os.LookupEnv(name); ok {
+			// with only a name, the CLI passes the value from its own environment without it appearing in the args
+			args = append(args, "-e", name)
+		}
+	}
+	if cfg.Workdir != "" {
+		args = append(args, "-w", cfg.Workdir)
+	}
+	if cfg.Entrypoint != "" {
+		args = append(args, "--entrypoint", cfg.Entrypoint)
+	}
+	args = append(args, image)
+	return append(args, cfg.Command...)
+}
+
+func buildArgs(tag, contextDir string, cfg Config) []string {
+	args := []string{"build", "-t", tag}
+	if cfg.Dockerfile != "" {
+		args = append(args, "-f", cfg.Dockerfile)
+	}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+	for _, key := range sortedKeys(cfg.BuildArgs) {
+		args = append(args, "--build-arg", key+"="+cfg.BuildArgs[key])
+	}
+	return append(args, contextDir)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_runArgs(t *testing.T) {
+	t.Setenv("DOCKER_TEST_PASSED", "secret")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "defaults",
+			expected: []string{"run", "--rm", "alpine"},
+		},
+		{
+			name: "all options",
+			opts: []Option{
+				Platform("linux/arm64"),
+				User("1000:1000"),
+				Mount("/src", "/dst"),
+				MountReadOnly("/cache", "/root/.cache"),
+				Env("B", "2"),
+				Env("A", "1"),
+				PassEnv("DOCKER_TEST_PASSED", "DOCKER_TEST_NOT_SET"),
+				Workdir("/dst"),
+				Entrypoint("/bin/sh"),
+				Command("-c", "echo hi"),
+			},
+			expected: []string{
+				"run", "--rm", "--platform", "linux/arm64", "--user", "1000:1000",
+				"-v", "/src:/dst", "-v", "/cache:/root/.cache:ro", "-e", "A=1", "-e", "B=2", "-e", "DOCKER_TEST_PASSED",
+				"-w", "/dst", "--entrypoint", "/bin/sh", "alpine", "-c", "echo hi",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, runArgs("alpine", newConfig(tt.opts...)))
+		})
+	}
+}
+
+func Test_MountRepo(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "some", "dir")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.SetAndRestore(t, &config.RootDir, root)
+
+	file.InDir(sub, func() {
+		cfg := newConfig(MountRepo())
+		require.Equal(t, 1, len(cfg.Mounts))
+		require.Equal(t, RepoMountPath, cfg.Mounts[0].Target)
+		require.Equal(t, RepoMountPath+"/some/dir", cfg.Workdir)
+	})
+	file.InDir(root, func() {
+		cfg := newConfig(MountRepo())
+		require.Equal(t, RepoMountPath, cfg.Workdir)
+	})
+}
+
+func Test_buildArgs(t *testing.T) {
+	args := buildArgs("my/image:dev", ".", newConfig(
+		Dockerfile("build/Dockerfile"),
+		Platform("linux/amd64"),
+		BuildArg("VERSION", "1.2.3"),
+		BuildArg("COMMIT", "abc"),
+	))
+	require.Equal(t, []string{
+		"build", "-t", "my/image:dev", "-f", "build/Dockerfile", "--platform", "linux/amd64",
+		"--build-arg", "COMMIT=abc", "--build-arg", "VERSION=1.2.3", ".",
+	}, args)
+}
+
+func Test_PassEnv(t *testing.T) {
+	if config.Windows {
+		t.Skip("fake container CLI is a shell script")
+	}
+	cli := filepath.Join(t.TempDir(), "fake-docker")
+	require.NoError(t, os.WriteFile(cli, []byte("#!/bin/sh\necho \"GOOS=$GOOS\"\n"), 0o700))
+	require.SetAndRestore(t, &config.ContainerCLI, cli)
+	t.Setenv("GOOS", "plan9")
+
+	// go environment variables are not passed to commands by default
+	require.Equal(t, "GOOS=", Run("alpine"))
+	require.Equal(t, "GOOS=plan9", Run("alpine", PassEnv("GOOS")))
+}
+
+func Test_CLI(t *testing.T) {
+	require.SetAndRestore(t, &config.ContainerCLI, "podman")
+	require.Equal(t, "podman", CLI())
+}
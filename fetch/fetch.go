@@ -5,9 +5,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 )
 
 type Option func(*fetchOptions) error
@@ -62,14 +64,14 @@ func Fetch(urlString string, options ...Option) (contents string, err error) {
 	}
 
 	log.Info("fetch: %s", urlString)
-	log.Debug("  └─ headers: %v", req.Header)
+	log.Debug("  └─ headers: %v", redactedHeaders(req.Header))
 
 	rsp := lang.Return(client.Do(req)) //nolint:bodyclose
 	defer lang.Close(rsp.Body, urlString)
 
 	// TODO: add a StatusCheck option
 	if rsp.StatusCode >= 300 {
-		err = lang.NewStackTraceError(fmt.Errorf("error: %v '%v' fetching: %v", rsp.StatusCode, rsp.Status, urlString))
+		err = lang.NewStackTraceError(&StatusError{StatusCode: rsp.StatusCode, Status: rsp.Status, URL: urlString})
 	}
 
 	if opts.writer != nil {
@@ -80,8 +82,36 @@ func Fetch(urlString string, options ...Option) (contents string, err error) {
 	return string(lang.Return(io.ReadAll(rsp.Body))), err
 }
 
+// StatusError is returned when a request completes with a non-success status code
+type StatusError struct {
+	StatusCode int
+	Status     string
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("error: %v '%v' fetching: %v", e.StatusCode, e.Status, e.URL)
+}
+
 type fetchOptions struct {
 	writer io.Writer
 	client *http.Client
 	req    *http.Request
 }
+
+// redactedHeaders returns a copy of the headers with credentials masked, for logging
+func redactedHeaders(headers http.Header) http.Header {
+	out := headers.Clone()
+	for _, name := range []string{"Authorization", "Proxy-Authorization", "Cookie"} {
+		for i, value := range out[name] {
+			// keep the scheme, e.g. "Bearer ***"
+			scheme, _, found := strings.Cut(value, " ")
+			if found {
+				out[name][i] = scheme + " " + redact.Replacement
+			} else {
+				out[name][i] = redact.Replacement
+			}
+		}
+	}
+	return out
+}
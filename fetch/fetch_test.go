@@ -1,6 +1,7 @@
 package fetch
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
@@ -43,6 +44,9 @@ func Test_Fetch(t *testing.T) {
 			path: "/file3",
 			validate: func(t *testing.T, _ string, err error) {
 				require.Contains(t, err.Error(), "404")
+				var statusErr *StatusError
+				require.True(t, errors.As(err, &statusErr))
+				require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
 			},
 		},
 	}
@@ -54,3 +58,17 @@ func Test_Fetch(t *testing.T) {
 		})
 	}
 }
+
+func Test_redactedHeaders(t *testing.T) {
+	headers := http.Header{
+		"Authorization":   {"Bearer some-token-value"},
+		"Cookie":          {"session-value"},
+		"X-Custom-Header": {"the-value"},
+	}
+	redacted := redactedHeaders(headers)
+	require.Equal(t, "Bearer ***", redacted.Get("Authorization"))
+	require.Equal(t, "***", redacted.Get("Cookie"))
+	require.Equal(t, "the-value", redacted.Get("X-Custom-Header"))
+	// the original headers are still sent
+	require.Equal(t, "Bearer some-token-value", headers.Get("Authorization"))
+}
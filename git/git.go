@@ -1,26 +1,40 @@
 package git
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/anchore/go-make/file"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/run"
-	"github.com/anchore/go-make/template"
 )
 
-func init() {
-	template.Globals["GitRoot"] = Root
+var (
+	// ErrDetachedHead is returned when HEAD does not reference a branch, as is typical for CI checkouts
+	ErrDetachedHead = errors.New("HEAD is detached")
+	// ErrNoTags is returned when no matching tags are found
+	ErrNoTags = errors.New("no tags found")
+)
+
+// Error is returned when a git command fails, including the exit code and stderr output
+type Error struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Err      error
 }
 
-func Root() string {
-	root := file.FindParent(file.Cwd(), ".git")
-	if root == "" {
-		panic(fmt.Errorf(".git not found"))
-	}
-	return filepath.Dir(root)
+func (e *Error) Error() string {
+	return fmt.Sprintf("git %s: exit code %d: %s", strings.Join(e.Args, " "), e.ExitCode, e.Stderr)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
 func Revision() string {
@@ -34,3 +48,193 @@ func InClone(repo, ref string, fn func()) {
 		fn()
 	})
 }
+
+// RevParse returns the full commit hash the ref points to, e.g. HEAD or v1.2.3
+func RevParse(ref string) (string, error) {
+	return git("rev-parse", "--verify", ref+"^{commit}")
+}
+
+// Branch returns the name of the current branch, or ErrDetachedHead
+func Branch() (string, error) {
+	branch, err := git("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		var gitErr *Error
+		if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+			return "", ErrDetachedHead
+		}
+		return "", err
+	}
+	return branch, nil
+}
+
+// DefaultBranch returns the default branch of the remote, e.g. main, based on the remote HEAD or
+// the existence of a main or master branch
+func DefaultBranch(remote string) (string, error) {
+	ref, err := git("symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err == nil {
+		return strings.TrimPrefix(ref, remote+"/"), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, e := git("show-ref", "--verify", "--quiet", "refs/remotes/"+remote+"/"+branch); e == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine default branch for remote %s: %w", remote, err)
+}
+
+// RemoteURL returns the fetch URL of the remote, e.g. git@github.com:anchore/go-make.git
+func RemoteURL(remote string) (string, error) {
+	return git("remote", "get-url", remote)
+}
+
+// RemoteRevision returns the commit SHA of the branch on the remote, queried from the remote rather than the
+// local remote-tracking branch, which may be out of date
+func RemoteRevision(remote, branch string) (string, error) {
+	out, err := git("ls-remote", "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	sha, _, _ := strings.Cut(out, "\t")
+	if sha == "" {
+		return "", fmt.Errorf("branch %s not found on remote %s", branch, remote)
+	}
+	return sha, nil
+}
+
+// IsDirty indicates the working tree has uncommitted changes to tracked files; untracked files are
+// not considered, consistent with `git describe --dirty`
+func IsDirty() (bool, error) {
+	status, err := git("status", "--porcelain", "--untracked-files=no")
+	return status != "", err
+}
+
+// Description is the parsed result of `git describe`
+type Description struct {
+	// Tag is the most recent tag reachable from HEAD, empty if no tags are reachable
+	Tag string
+	// Distance is the number of commits since Tag
+	Distance int
+	// Hash is the abbreviated commit hash of HEAD
+	Hash string
+	// Dirty indicates uncommitted changes to tracked files
+	Dirty bool
+}
+
+func (d Description) String() string {
+	out := d.Hash
+	if d.Tag != "" {
+		out = fmt.Sprintf("%s-%d-g%s", d.Tag, d.Distance, d.Hash)
+	}
+	if d.Dirty {
+		out += "-dirty"
+	}
+	return out
+}
+
+var (
+	describePattern     = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]+)$`)
+	describeHashPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+)
+
+// Describe describes HEAD relative to the most recent tag, optionally limited to tags matching the glob patterns
+func Describe(match ...string) (Description, error) {
+	args := []string{"describe", "--tags", "--long", "--always", "--dirty", "--abbrev=7"}
+	for _, m := range match {
+		args = append(args, "--match", m)
+	}
+	out, err := git(args...)
+	if err != nil {
+		return Description{}, err
+	}
+	return parseDescription(out)
+}
+
+func parseDescription(out string) (Description, error) {
+	d := Description{}
+	out, d.Dirty = strings.CutSuffix(out, "-dirty")
+	if describeHashPattern.MatchString(out) {
+		d.Hash = out
+		return d, nil
+	}
+	parts := describePattern.FindStringSubmatch(out)
+	if parts == nil {
+		return d, fmt.Errorf("unable to parse git describe output: %s", out)
+	}
+	d.Tag = parts[1]
+	d.Distance = lang.Return(strconv.Atoi(parts[2]))
+	d.Hash = parts[3]
+	return d, nil
+}
+
+// MergeBase returns the best common ancestor commit of the refs
+func MergeBase(a, b string) (string, error) {
+	return git("merge-base", a, b)
+}
+
+// IsAncestor indicates the ancestor commit is reachable from the ref, e.g. a tagged commit was merged to a branch
+func IsAncestor(ancestor, ref string) (bool, error) {
+	_, err := git("merge-base", "--is-ancestor", ancestor, ref)
+	var gitErr *Error
+	if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Fetch fetches the refs from the remote, such as a branch, without updating local branches
+func Fetch(remote string, refs ...string) error {
+	_, err := git(append([]string{"fetch", remote}, refs...)...)
+	return err
+}
+
+// IsShallow indicates the repository is a shallow clone, without all history
+func IsShallow() (bool, error) {
+	out, err := git("rev-parse", "--is-shallow-repository")
+	return out == "true", err
+}
+
+// ChangedFiles returns files changed between the from and to refs, relative to the root; if to is empty,
+// changes are compared to the working tree, including uncommitted changes to tracked files
+func ChangedFiles(from, to string) ([]string, error) {
+	args := []string{"diff", "--name-only", "--no-renames", from}
+	if to != "" {
+		args = append(args, to)
+	}
+	return lines(git(args...))
+}
+
+// UntrackedFiles returns all untracked files not ignored, relative to the root
+func UntrackedFiles() ([]string, error) {
+	return lines(git("ls-files", "--others", "--exclude-standard", "--full-name", ":/"))
+}
+
+func git(args ...string) (string, error) {
+	stderr := bytes.Buffer{}
+	out, err := run.Command("git", run.Args(args...), run.Quiet(), run.Stderr(&stderr))
+	if err != nil {
+		gitErr := &Error{
+			Args:   args,
+			Stderr: strings.TrimSpace(stderr.String()),
+			Err:    err,
+		}
+		var stackErr *lang.StackTraceError
+		if errors.As(err, &stackErr) {
+			gitErr.ExitCode = stackErr.ExitCode
+		}
+		return out, gitErr
+	}
+	return out, nil
+}
+
+func lines(out string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,276 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/template"
+)
+
+func Test_Repository(t *testing.T) {
+	defer require.Test(t)
+
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "feat: first feature", map[string]string{"a.go": "package a"})
+	require.Git(t, dir, "tag", "-a", "v0.1.0", "-m", "v0.1.0")
+	require.GitCommit(t, dir, "fix: something\n\nlonger description", map[string]string{"b.go": "package b"})
+	require.Git(t, dir, "tag", "v0.2.0-rc.1")
+	require.Git(t, dir, "tag", "not-a-version")
+
+	file.InDir(dir, func() {
+		branch, err := Branch()
+		require.NoError(t, err)
+		require.Equal(t, "main", branch)
+
+		tags, err := TagsAtHead()
+		require.NoError(t, err)
+		require.EqualElements(t, []string{"not-a-version", "v0.2.0-rc.1"}, tags)
+
+		latest, err := LatestSemverTag()
+		require.NoError(t, err)
+		require.Equal(t, "v0.1.0", latest)
+
+		latest, err = LatestSemverTagAt("HEAD^")
+		require.NoError(t, err)
+		require.Equal(t, "v0.1.0", latest)
+
+		_, err = LatestSemverTagAt("HEAD^^")
+		require.True(t, errors.Is(err, ErrNoTags))
+
+		d, err := Describe("v*")
+		require.NoError(t, err)
+		require.Equal(t, "v0.2.0-rc.1", d.Tag)
+		require.Equal(t, 0, d.Distance)
+
+		d, err = Describe("v0.1.*")
+		require.NoError(t, err)
+		require.Equal(t, "v0.1.0", d.Tag)
+		require.Equal(t, 1, d.Distance)
+		require.Equal(t, Revision(), d.Hash)
+		require.True(t, !d.Dirty)
+
+		commits, err := CommitsSince("v0.1.0")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commits))
+		require.Equal(t, "fix: something", commits[0].Subject)
+		require.Equal(t, "longer description", commits[0].Body)
+		require.Equal(t, "Test User", commits[0].Author)
+		require.Equal(t, lang.Return(RevParse("HEAD")), commits[0].SHA)
+
+		commits, err = CommitsSince("")
+		require.NoError(t, err)
+		require.Equal(t, 3, len(commits))
+
+		ancestor, err := IsAncestor("v0.1.0", "HEAD")
+		require.NoError(t, err)
+		require.True(t, ancestor)
+
+		ancestor, err = IsAncestor("HEAD", "v0.1.0")
+		require.NoError(t, err)
+		require.True(t, !ancestor)
+
+		changed, err := ChangedFiles("v0.1.0", "HEAD")
+		require.NoError(t, err)
+		require.EqualElements(t, []string{"b.go"}, changed)
+
+		dirty, err := IsDirty()
+		require.NoError(t, err)
+		require.True(t, !dirty)
+
+		require.NoError(t, os.WriteFile("untracked.go", []byte("package c"), 0o600))
+		dirty, err = IsDirty()
+		require.NoError(t, err)
+		require.True(t, !dirty)
+
+		untracked, err := UntrackedFiles()
+		require.NoError(t, err)
+		require.EqualElements(t, []string{"untracked.go"}, untracked)
+
+		require.NoError(t, os.WriteFile("a.go", []byte("package changed"), 0o600))
+		dirty, err = IsDirty()
+		require.NoError(t, err)
+		require.True(t, dirty)
+
+		d, err = Describe()
+		require.NoError(t, err)
+		require.True(t, d.Dirty)
+	})
+}
+
+func Test_RemoteRevision(t *testing.T) {
+	defer require.Test(t)
+
+	dir := require.GitRepo(t)
+	origin := t.TempDir()
+	require.Git(t, origin, "init", "--bare", "--initial-branch=main")
+	require.Git(t, dir, "remote", "add", "origin", origin)
+	require.Git(t, dir, "push", "origin", "main")
+	pushed := require.Git(t, dir, "rev-parse", "HEAD")
+	require.GitCommit(t, dir, "not pushed", nil)
+
+	file.InDir(dir, func() {
+		sha, err := RemoteRevision("origin", "main")
+		require.NoError(t, err)
+		require.Equal(t, pushed, sha)
+
+		_, err = RemoteRevision("origin", "missing")
+		require.Error(t, err)
+	})
+}
+
+func Test_Tags(t *testing.T) {
+	defer require.Test(t)
+
+	dir := require.GitRepo(t)
+	remote := t.TempDir()
+	require.Git(t, remote, "init", "--bare", "--initial-branch=main")
+	require.Git(t, dir, "remote", "add", "origin", remote)
+	require.Git(t, dir, "push", "origin", "main")
+
+	file.InDir(dir, func() {
+		_, err := LatestSemverTag()
+		require.True(t, errors.Is(err, ErrNoTags))
+
+		first := lang.Return(RevParse("HEAD"))
+		require.GitCommit(t, dir, "second", nil)
+
+		require.NoError(t, CreateTag("v1.0.0", "release v1.0.0"))
+		require.NoError(t, CreateTag("latest", "latest", At(first)))
+		require.Error(t, CreateTag("latest", "latest"))
+		require.NoError(t, CreateTag("latest", "latest", Force()))
+		require.Equal(t, lang.Return(RevParse("HEAD")), lang.Return(RevParse("latest")))
+
+		require.NoError(t, PushTag("origin", "v1.0.0"))
+		require.Equal(t, lang.Return(RevParse("v1.0.0")), require.Git(t, remote, "rev-parse", "v1.0.0^{commit}"))
+
+		require.Git(t, remote, "tag", "remote-only", "main")
+		require.NoError(t, FetchTags())
+		_, err = RevParse("remote-only")
+		require.NoError(t, err)
+
+		branch, err := DefaultBranch("origin")
+		require.NoError(t, err)
+		require.Equal(t, "main", branch)
+
+		require.Git(t, dir, "checkout", "--detach", "HEAD")
+		_, err = Branch()
+		require.True(t, errors.Is(err, ErrDetachedHead))
+
+		_, err = RevParse("does-not-exist")
+		var gitErr *Error
+		require.True(t, errors.As(err, &gitErr))
+		require.True(t, gitErr.ExitCode > 0)
+	})
+}
+
+func Test_parseDescription(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Description
+	}{
+		{
+			input:    "v1.2.3-0-g1a2b3c4",
+			expected: Description{Tag: "v1.2.3", Hash: "1a2b3c4"},
+		},
+		{
+			input:    "v1.2.3-rc-1-5-g1a2b3c4-dirty",
+			expected: Description{Tag: "v1.2.3-rc-1", Distance: 5, Hash: "1a2b3c4", Dirty: true},
+		},
+		{
+			input:    "1a2b3c4",
+			expected: Description{Hash: "1a2b3c4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := parseDescription(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, d)
+			require.Equal(t, tt.input, d.String())
+		})
+	}
+}
+
+func Test_Root(t *testing.T) {
+	dir := require.GitRepo(t)
+	sub := filepath.Join(dir, "some", "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	file.InDir(sub, func() {
+		require.Equal(t, lang.Return(filepath.EvalSymlinks(dir)), lang.Return(filepath.EvalSymlinks(Root())))
+		require.Equal(t, Root(), ProjectRoot())
+	})
+}
+
+func Test_RootWorktree(t *testing.T) {
+	dir := lang.Return(filepath.EvalSymlinks(require.GitRepo(t)))
+	worktree := filepath.Join(lang.Return(filepath.EvalSymlinks(t.TempDir())), "wt")
+	require.Git(t, dir, "worktree", "add", "-b", "other", worktree)
+
+	sub := filepath.Join(worktree, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	file.InDir(sub, func() {
+		require.Equal(t, worktree, Root())
+		require.Equal(t, filepath.Join(dir, ".git", "worktrees", "wt"), Dir())
+		require.Equal(t, filepath.Join(dir, ".git"), CommonDir())
+
+		branch, err := Branch()
+		require.NoError(t, err)
+		require.Equal(t, "other", branch)
+	})
+	file.InDir(dir, func() {
+		require.Equal(t, filepath.Join(dir, ".git"), Dir())
+		require.Equal(t, filepath.Join(dir, ".git"), CommonDir())
+	})
+}
+
+func Test_RootGitFile(t *testing.T) {
+	dir := lang.Return(filepath.EvalSymlinks(t.TempDir()))
+	module := filepath.Join(dir, "modules", "child")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(module, 0o755))
+
+	// a submodule checkout has a .git file pointing to the superproject's git directory
+	child := filepath.Join(dir, "child")
+	require.NoError(t, os.MkdirAll(filepath.Join(child, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(child, ".git"), []byte("gitdir: ../modules/child\n"), 0o600))
+
+	// an unrelated .git file is not treated as a repository root
+	other := filepath.Join(dir, "other")
+	require.NoError(t, os.MkdirAll(other, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, ".git"), []byte("not a pointer"), 0o600))
+
+	file.InDir(filepath.Join(child, "pkg"), func() {
+		require.Equal(t, child, Root())
+		require.Equal(t, module, Dir())
+	})
+	file.InDir(other, func() {
+		require.Equal(t, dir, Root())
+	})
+}
+
+func Test_ProjectRoot(t *testing.T) {
+	dir := lang.Return(filepath.EvalSymlinks(require.GitRepo(t)))
+	project := filepath.Join(dir, "services", "api")
+	pkg := filepath.Join(project, "pkg")
+	require.NoError(t, os.MkdirAll(pkg, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(project, ".make"), 0o755))
+
+	require.SetAndRestore(t, &config.RootMarker, ".make")
+	file.InDir(pkg, func() {
+		require.Equal(t, project, ProjectRoot())
+		require.Equal(t, project, template.Render(config.RootDir))
+		require.Equal(t, dir, template.Render("{{GitRoot}}"))
+	})
+
+	// markers outside the repository are ignored
+	require.SetAndRestore(t, &config.RootMarker, "marker-not-present")
+	file.InDir(pkg, func() {
+		require.Equal(t, dir, ProjectRoot())
+	})
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a single commit from the git log
+type Commit struct {
+	SHA     string
+	Author  string
+	Email   string
+	Date    time.Time
+	Subject string
+	Body    string
+}
+
+const (
+	fieldSeparator  = "\x1f"
+	recordSeparator = "\x1e"
+)
+
+// CommitsSince returns commits reachable from HEAD but not from the ref, most recent first;
+// if ref is empty, all commits reachable from HEAD are returned
+func CommitsSince(ref string) ([]Commit, error) {
+	format := strings.Join([]string{"%H", "%an", "%ae", "%aI", "%s", "%b"}, "%x1f") + "%x1e"
+	revisions := "HEAD"
+	if ref != "" {
+		revisions = ref + "..HEAD"
+	}
+	out, err := git("log", "--format="+format, revisions, "--")
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, recordSeparator) {
+		fields := strings.Split(strings.TrimSpace(record), fieldSeparator)
+		if len(fields) < 6 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    date,
+			Subject: fields[4],
+			Body:    strings.TrimSpace(fields[5]),
+		})
+	}
+	return commits, nil
+}
+
+// CommitCount returns the number of commits reachable from the ref
+func CommitCount(ref string) (int, error) {
+	out, err := git("rev-list", "--count", ref)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
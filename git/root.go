@@ -0,0 +1,104 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/template"
+)
+
+const gitdirPrefix = "gitdir:"
+
+func init() {
+	template.Globals["GitRoot"] = Root
+	template.Globals["ProjectRoot"] = ProjectRoot
+}
+
+// Root returns the root of the working tree containing the current directory. The .git entry may be a directory
+// or, for worktrees and submodules, a file with a gitdir pointer
+func Root() string {
+	root := findRoot(file.Cwd())
+	if root == "" {
+		panic(fmt.Errorf(".git not found"))
+	}
+	return root
+}
+
+// Dir returns the git directory for the current working tree, following gitdir pointers; for a linked worktree
+// this is the worktree-specific directory, e.g. <repo>/.git/worktrees/<name>
+func Dir() string {
+	return lang.Return(gitDir(filepath.Join(Root(), ".git")))
+}
+
+// CommonDir returns the git directory shared by all worktrees, which contains refs, objects and config
+func CommonDir() string {
+	dir := Dir()
+	contents, err := os.ReadFile(filepath.Join(dir, "commondir"))
+	if err != nil {
+		return dir
+	}
+	common := strings.TrimSpace(string(contents))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	return filepath.Clean(common)
+}
+
+// ProjectRoot returns the nearest parent directory containing config.RootMarker, e.g. .make or go.mod, stopping at the
+// git root; if no marker is configured or found, this is the git root
+func ProjectRoot() string {
+	gitRoot := template.Render("{{GitRoot}}")
+	if config.RootMarker == "" {
+		return gitRoot
+	}
+	for dir := file.Cwd(); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, config.RootMarker)); err == nil {
+			return dir
+		}
+		if dir == gitRoot || dir == filepath.Dir(dir) {
+			return gitRoot
+		}
+	}
+}
+
+func findRoot(dir string) string {
+	for {
+		if _, err := gitDir(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if dir == filepath.Dir(dir) {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// gitDir returns the git directory for the .git entry at path, which is either the directory itself or a file
+// containing "gitdir: <path>", relative to the file location
+func gitDir(path string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return path, nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(contents))
+	if !strings.HasPrefix(line, gitdirPrefix) {
+		return "", fmt.Errorf("invalid .git file: %s", path)
+	}
+	dir := strings.TrimSpace(strings.TrimPrefix(line, gitdirPrefix))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return filepath.Clean(dir), nil
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Option modifies tag and remote operations
+type Option func(*options)
+
+type options struct {
+	force bool
+	prune bool
+	at    string
+}
+
+// Force replaces existing tags when creating, pushing or fetching
+func Force() Option {
+	return func(o *options) {
+		o.force = true
+	}
+}
+
+// Prune removes local tags no longer present on the remote when fetching
+func Prune() Option {
+	return func(o *options) {
+		o.prune = true
+	}
+}
+
+// At specifies the ref to create a tag at, instead of HEAD
+func At(ref string) Option {
+	return func(o *options) {
+		o.at = ref
+	}
+}
+
+func applyOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Tags returns all tags pointing at the ref, e.g. HEAD
+func Tags(ref string) ([]string, error) {
+	return lines(git("tag", "--points-at", ref))
+}
+
+// TagsAtHead returns all tags pointing at HEAD
+func TagsAtHead() ([]string, error) {
+	return Tags("HEAD")
+}
+
+var releaseTagPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+
+// LatestSemverTag returns the highest release version tag reachable from HEAD, e.g. v1.2.3; pre-release
+// tags such as v1.2.3-rc.1 are not considered. Returns ErrNoTags if none are found
+func LatestSemverTag() (string, error) {
+	return LatestSemverTagAt("HEAD")
+}
+
+// LatestSemverTagAt returns the highest release version tag reachable from the ref, see LatestSemverTag
+func LatestSemverTagAt(ref string) (string, error) {
+	tags, err := lines(git("tag", "--merged", ref))
+	if err != nil {
+		return "", err
+	}
+	latest := ""
+	var latestVersion [3]int
+	for _, tag := range tags {
+		parts := releaseTagPattern.FindStringSubmatch(tag)
+		if parts == nil {
+			continue
+		}
+		var version [3]int
+		for i := range version {
+			version[i], _ = strconv.Atoi(parts[i+1])
+		}
+		if latest == "" || slices.Compare(version[:], latestVersion[:]) > 0 {
+			latest, latestVersion = tag, version
+		}
+	}
+	if latest == "" {
+		return "", ErrNoTags
+	}
+	return latest, nil
+}
+
+// CreateTag creates an annotated tag at HEAD, or the ref specified with At
+func CreateTag(name, message string, opts ...Option) error {
+	o := applyOptions(opts)
+	args := []string{"tag", "--annotate", "--message", message}
+	if o.force {
+		args = append(args, "--force")
+	}
+	args = append(args, name)
+	if o.at != "" {
+		args = append(args, o.at)
+	}
+	_, err := git(args...)
+	return err
+}
+
+// PushTag pushes the tag to the remote, e.g. origin
+func PushTag(remote, name string, opts ...Option) error {
+	o := applyOptions(opts)
+	args := []string{"push", remote}
+	if o.force {
+		args = append(args, "--force")
+	}
+	_, err := git(append(args, "refs/tags/"+name+":refs/tags/"+name)...)
+	return err
+}
+
+// FetchTags fetches all tags from the default remote
+func FetchTags(opts ...Option) error {
+	o := applyOptions(opts)
+	args := []string{"fetch", "--tags"}
+	if o.force {
+		args = append(args, "--force")
+	}
+	if o.prune {
+		args = append(args, "--prune", "--prune-tags")
+	}
+	_, err := git(args...)
+	return err
+}
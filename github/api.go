@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -21,8 +23,12 @@ import (
 	"github.com/anchore/go-make/git"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 )
 
+// ErrNoWorkflowRun is returned when no successful run of the workflow is found on the branch
+var ErrNoWorkflowRun = errors.New("no workflow run found")
+
 type Option func(Api)
 
 type Api struct {
@@ -40,7 +46,7 @@ type Api struct {
 }
 
 // NewClient creates a new GitHub API client, looking up information automatically from the environment
-// when run in a GitHub Actions runner
+// when run in a GitHub Actions runner, or the `gh auth token` and origin remote when run locally
 func NewClient(params ...Param) Api {
 	p := Payload() // look up payload when running on github runners
 
@@ -53,6 +59,7 @@ func NewClient(params ...Param) Api {
 		// try to get locally authenticated token
 		p.Token = Run("gh auth token")
 	}
+	redact.Add(p.Token)
 
 	a := Api{
 		Token:   p.Token,
@@ -68,12 +75,61 @@ func NewClient(params ...Param) Api {
 			a.Actor = param.value
 		case "token":
 			a.Token = param.value
+			redact.Add(a.Token)
 		}
 	}
 
+	if a.Repo == "" {
+		a.Repo = remoteRepo()
+	}
+
 	return a
 }
 
+var githubRepoPattern = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+// remoteRepo returns the GitHub repository of the origin remote, e.g. anchore/syft, or "" if it is not a GitHub remote
+func remoteRepo() string {
+	url, err := git.RemoteURL("origin")
+	if err != nil {
+		log.Debug("unable to get origin remote URL: %v", err)
+		return ""
+	}
+	if parts := githubRepoPattern.FindStringSubmatch(url); parts != nil {
+		return parts[1]
+	}
+	return ""
+}
+
+// PullRequestsForCommit returns the pull requests associated with a commit, such as the pull request it was merged by
+func (a Api) PullRequestsForCommit(sha string) ([]PullRequest, error) {
+	return get[[]PullRequest](a, "/repos/%s/commits/%s/pulls", a.Repo, sha)
+}
+
+// CombinedStatus returns the combined commit status for a ref, such as a commit SHA
+func (a Api) CombinedStatus(ref string) (CombinedStatus, error) {
+	return get[CombinedStatus](a, "/repos/%s/commits/%s/status", a.Repo, ref)
+}
+
+// CheckRuns returns the check runs for a ref, such as a commit SHA
+func (a Api) CheckRuns(ref string) ([]CheckRun, error) {
+	rsp, err := get[CheckRunList](a, "/repos/%s/commits/%s/check-runs?per_page=100", a.Repo, ref)
+	return rsp.CheckRuns, err
+}
+
+// ReleaseByTag returns the published release for a tag, or nil if there is none
+func (a Api) ReleaseByTag(tag string) (*Release, error) {
+	release, err := get[Release](a, "/repos/%s/releases/tags/%s", a.Repo, tag)
+	var statusErr *fetch.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
 func (a Api) DeleteArtifact(artifactID int64) error {
 	return fetch.Delete(a.BaseURL+fmt.Sprintf("/repos/%s/actions/artifacts/%v", a.Repo, artifactID), a.headers())
 }
@@ -101,7 +157,7 @@ func (a Api) LatestWorkflowRun(branch, workflowNameGlob string) (WorkflowRun, er
 			return run, nil
 		}
 	}
-	return WorkflowRun{}, fmt.Errorf("no workflow run found for %s workflow: %s", a.Repo, workflowNameGlob)
+	return WorkflowRun{}, fmt.Errorf("%w for %s workflow: %s", ErrNoWorkflowRun, a.Repo, workflowNameGlob)
 }
 
 // ListArtifactsForBranch returns artifacts for the latest run on the given workflow
@@ -111,7 +167,7 @@ func (a Api) ListArtifactsForBranch(branch, workflowNameGlob, artifactNameGlob s
 		return nil, err
 	}
 	if latestRun.ID == 0 {
-		return nil, fmt.Errorf("no workflow run found for %s workflow: %s", a.Repo, workflowNameGlob)
+		return nil, fmt.Errorf("%w for %s workflow: %s", ErrNoWorkflowRun, a.Repo, workflowNameGlob)
 	}
 	return a.ListArtifactsForWorkflowRun(latestRun.ID, artifactNameGlob)
 }
@@ -141,7 +197,7 @@ func (a Api) DownloadBranchArtifactDir(branch, workflowName, artifactName, targe
 		return err
 	}
 	if latestRun.ID == 0 {
-		return fmt.Errorf("no workflow run found for %s workflow: %s", a.Repo, workflowName)
+		return fmt.Errorf("%w for %s workflow: %s", ErrNoWorkflowRun, a.Repo, workflowName)
 	}
 	return a.DownloadArtifactDir(latestRun.ID, artifactName, targetDir)
 }
@@ -291,6 +347,11 @@ var envFile = sync.OnceValue(func() map[string]string {
 		defer lang.Close(f, p)
 		log.Error(json.NewDecoder(f).Decode(&out))
 	}
+	for k, v := range out {
+		if redact.IsSecretName(k) {
+			redact.Add(v)
+		}
+	}
 	log.Debug("read env file %v: %v", p, out)
 	return out
 })
@@ -30,6 +30,27 @@ func Test_ListWorkflowRunsBranch(t *testing.T) {
 	require.Equal(t, 3, len(runs))
 }
 
+func Test_ReleaseByTag(t *testing.T) {
+	defer require.Test(t)
+
+	baseURL := require.Server(t, map[string]any{
+		"/repos/testorg/testrepo/releases/tags/v1.0.0": Release{ID: 5, TagName: "v1.0.0"},
+	})
+
+	testrepo := Api{
+		BaseURL: baseURL,
+		Repo:    "testorg/testrepo",
+	}
+
+	release, err := testrepo.ReleaseByTag("v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), release.ID)
+
+	release, err = testrepo.ReleaseByTag("v2.0.0")
+	require.NoError(t, err)
+	require.True(t, release == nil)
+}
+
 func Test_ListArtifactsForCommit(t *testing.T) {
 	defer require.Test(t)
 
@@ -186,3 +207,19 @@ func fileMap(files map[string]string) map[string]any {
 	}
 	return out
 }
+
+func Test_remoteRepo(t *testing.T) {
+	dir := require.GitRepo(t)
+	file.InDir(dir, func() {
+		require.Equal(t, "", remoteRepo())
+
+		require.Git(t, dir, "remote", "add", "origin", "git@github.com:anchore/go-make.git")
+		require.Equal(t, "anchore/go-make", remoteRepo())
+
+		require.Git(t, dir, "remote", "set-url", "origin", "https://github.com/anchore/syft")
+		require.Equal(t, "anchore/syft", remoteRepo())
+
+		require.Git(t, dir, "remote", "set-url", "origin", "https://gitlab.com/anchore/syft.git")
+		require.Equal(t, "", remoteRepo())
+	})
+}
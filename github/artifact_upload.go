@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 
@@ -108,6 +109,11 @@ Promise.all([artifact.uploadArtifact(archiveName, files, baseDir, opts).then(({
 	return strconv.ParseInt(id, 10, 64)
 }
 
+// ArtifactFiles returns the absolute paths of the files UploadArtifactDir would upload from the baseDir with the options
+func ArtifactFiles(baseDir string, opts UploadArtifactOption) []string {
+	return listMatchingFiles(baseDir, &opts)
+}
+
 func listMatchingFiles(baseDir string, opts *UploadArtifactOption) []string {
 	var out []string
 	baseDir = lang.Return(filepath.Abs(baseDir))
@@ -137,15 +143,19 @@ func ensureActionsArtifactInstalled(path string) {
 	file.EnsureDir(path)
 	file.InDir(path, func() {
 		if !isActionsArtifactInstalled() {
-			if nil != lang.Catch(func() {
-				Run("npm install @actions/artifact@latest")
-			}) {
-				Run("npm install @actions/artifact@" + knownActionsArtifactVersion)
-			}
+			Run("npm install @actions/artifact@latest", npmRetry(), run.NoFail())
+		}
+		if !isActionsArtifactInstalled() {
+			Run("npm install @actions/artifact@"+knownActionsArtifactVersion, npmRetry())
 		}
 	})
 }
 
+// npmRetry retries npm installs, which intermittently fail due to network issues in CI
+func npmRetry() run.Option {
+	return run.Retry(3, run.ExponentialBackoff(2*time.Second), nil)
+}
+
 func isActionsArtifactInstalled() bool {
 	return strings.Contains(Run("npm list @actions/artifact", run.Quiet(), run.NoFail()), "@actions/artifact")
 }
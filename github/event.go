@@ -3,15 +3,30 @@ package github
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/anchore/go-make/config"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
 )
 
+// Event types, see:
+// https://docs.github.com/en/actions/reference/workflows-and-actions/events-that-trigger-workflows
+const (
+	PushEvent             = "push"
+	PullRequestEvent      = "pull_request"
+	PullRequestTarget     = "pull_request_target"
+	ReleaseEvent          = "release"
+	WorkflowDispatchEvent = "workflow_dispatch"
+	MergeGroupEvent       = "merge_group"
+	ScheduleEvent         = "schedule"
+)
+
 // Environment variable reference:
 // https://docs.github.com/en/actions/reference/workflows-and-actions/variables#default-environment-variables
 
@@ -31,10 +46,114 @@ type Event struct {
 	Step        string      `env:"GITHUB_STEP"`
 	Action      string      `env:"GITHUB_ACTION"`
 	PullRequest PullRequest `json:"pull_request"`
+
+	// the remaining fields are read from the event payload, and are only populated for the relevant event types
+
+	// EventAction is the activity type of the event, e.g. opened or synchronize for pull_request events
+	EventAction  string         `json:"action"`
+	WorkflowPath string         `json:"workflow"` // WorkflowPath is the workflow file for workflow_dispatch events
+	Before       string         `json:"before"`
+	After        string         `json:"after"`
+	Created      bool           `json:"created"`
+	Deleted      bool           `json:"deleted"`
+	Forced       bool           `json:"forced"`
+	BaseRef      string         `json:"base_ref"`
+	Compare      string         `json:"compare"`
+	Commits      []Commit       `json:"commits"`
+	HeadCommit   Commit         `json:"head_commit"`
+	Pusher       CommitAuthor   `json:"pusher"`
+	Release      Release        `json:"release"`
+	MergeGroup   MergeGroup     `json:"merge_group"`
+	Schedule     string         `json:"schedule"` // Schedule is the cron expression for schedule events
+	Repository   Repository     `json:"repository"`
+	Sender       User           `json:"sender"`
+	RawInputs    map[string]any `json:"inputs"` // RawInputs are the workflow_dispatch inputs, see Inputs
 }
 
 func (e Event) IsPullRequest() bool {
-	return e.PullRequest != PullRequest{}
+	return e.PullRequest.Number != 0 || e.PullRequest.URL != ""
+}
+
+// IsPush indicates this is a push event, for either a branch or tag
+func (e Event) IsPush() bool {
+	return e.Type == PushEvent
+}
+
+// IsRelease indicates this is a release event
+func (e Event) IsRelease() bool {
+	return e.Type == ReleaseEvent
+}
+
+// IsWorkflowDispatch indicates this is a manually triggered workflow_dispatch event
+func (e Event) IsWorkflowDispatch() bool {
+	return e.Type == WorkflowDispatchEvent
+}
+
+// IsMergeGroup indicates this is a merge queue event
+func (e Event) IsMergeGroup() bool {
+	return e.Type == MergeGroupEvent
+}
+
+// IsSchedule indicates this is a scheduled event
+func (e Event) IsSchedule() bool {
+	return e.Type == ScheduleEvent
+}
+
+// Tag returns the tag name for tag pushes and release events, or an empty string
+func (e Event) Tag() string {
+	if tag, ok := strings.CutPrefix(e.Ref, "refs/tags/"); ok {
+		return tag
+	}
+	if e.IsRelease() {
+		return e.Release.TagName
+	}
+	return ""
+}
+
+// Branch returns the branch name being built: the head branch for pull requests and merge groups,
+// the branch pushed to for pushes, or an empty string
+func (e Event) Branch() string {
+	if e.IsPullRequest() {
+		return e.PullRequest.Head.Ref
+	}
+	if e.IsMergeGroup() {
+		return strings.TrimPrefix(e.MergeGroup.HeadRef, "refs/heads/")
+	}
+	if branch, ok := strings.CutPrefix(e.Ref, "refs/heads/"); ok {
+		return branch
+	}
+	return ""
+}
+
+// ChangedFiles returns the sorted, unique files added, modified or removed by the commits in a push event;
+// other event payloads do not include changed files, and this returns nil
+func (e Event) ChangedFiles() []string {
+	files := map[string]struct{}{}
+	for _, c := range e.Commits {
+		for _, f := range slices.Concat(c.Added, c.Modified, c.Removed) {
+			files[f] = struct{}{}
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(files))
+}
+
+// Inputs returns the workflow_dispatch inputs as strings, e.g. boolean inputs are "true" or "false"
+func (e Event) Inputs() map[string]string {
+	out := map[string]string{}
+	for k, v := range e.RawInputs {
+		switch v := v.(type) {
+		case string:
+			out[k] = v
+		case nil:
+			out[k] = ""
+		default:
+			out[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return out
 }
 
 // Payload returns the current event payload
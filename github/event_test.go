@@ -0,0 +1,108 @@
+package github
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-make/require"
+)
+
+func Test_EventPayloads(t *testing.T) {
+	tests := []struct {
+		eventName string
+		eventFile string
+		env       map[string]string
+		validate  func(t *testing.T, e Event)
+	}{
+		{
+			eventName: PushEvent,
+			eventFile: "push_tag.json",
+			env: map[string]string{
+				"GITHUB_ACTION": "__run_2",
+			},
+			validate: func(t *testing.T, e Event) {
+				require.True(t, e.IsPush())
+				require.True(t, e.Created)
+				require.Equal(t, "v1.2.3", e.Tag())
+				require.Equal(t, "", e.Branch())
+				require.Equal(t, "refs/heads/main", e.BaseRef)
+				require.Equal(t, "chore: release", e.HeadCommit.Message)
+				require.Equal(t, "__run_2", e.Action)
+				require.Equal(t, 0, len(e.ChangedFiles()))
+			},
+		},
+		{
+			eventName: PushEvent,
+			eventFile: "push_commits.json",
+			validate: func(t *testing.T, e Event) {
+				require.Equal(t, "", e.Tag())
+				require.Equal(t, "main", e.Branch())
+				require.Equal(t, "1e7a3e6f0b1b4b3c7d5c6d2a0e9f8a7b6c5d4e3f", e.Before)
+				require.Equal(t, 2, len(e.Commits))
+				require.Equal(t, "someperson", e.Commits[0].Author.Username)
+				require.Equal(t, []string{"docs/new.md", "go.mod", "old.go", "run/run.go"}, e.ChangedFiles())
+				require.Equal(t, "main", e.Repository.DefaultBranch)
+			},
+		},
+		{
+			eventName: ReleaseEvent,
+			eventFile: "release.json",
+			validate: func(t *testing.T, e Event) {
+				require.True(t, e.IsRelease())
+				require.Equal(t, "published", e.EventAction)
+				require.Equal(t, "v1.2.3", e.Tag())
+				require.Equal(t, "someperson", e.Release.Author.Login)
+			},
+		},
+		{
+			eventName: WorkflowDispatchEvent,
+			eventFile: "workflow_dispatch.json",
+			env: map[string]string{
+				"GITHUB_WORKFLOW": "Release",
+			},
+			validate: func(t *testing.T, e Event) {
+				require.True(t, e.IsWorkflowDispatch())
+				require.Equal(t, "Release", e.Workflow)
+				require.Equal(t, ".github/workflows/release.yaml", e.WorkflowPath)
+				require.Equal(t, map[string]string{
+					"version": "v1.2.3",
+					"dry-run": "true",
+					"count":   "3",
+				}, e.Inputs())
+			},
+		},
+		{
+			eventName: MergeGroupEvent,
+			eventFile: "merge_group.json",
+			validate: func(t *testing.T, e Event) {
+				require.True(t, e.IsMergeGroup())
+				require.True(t, !e.IsPullRequest())
+				require.Equal(t, "1e7a3e6f0b1b4b3c7d5c6d2a0e9f8a7b6c5d4e3f", e.MergeGroup.BaseSHA)
+				require.Equal(t, "gh-readonly-queue/main/pr-42-1e7a3e6f0b1b4b3c7d5c6d2a0e9f8a7b6c5d4e3f", e.Branch())
+			},
+		},
+		{
+			eventName: ScheduleEvent,
+			eventFile: "schedule.json",
+			validate: func(t *testing.T, e Event) {
+				require.True(t, e.IsSchedule())
+				require.Equal(t, "0 0 * * 0", e.Schedule)
+				require.Equal(t, 0, len(e.Inputs()))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventFile, func(t *testing.T) {
+			defer require.Test(t)
+
+			t.Setenv("GITHUB_EVENT_NAME", tt.eventName)
+			t.Setenv("GITHUB_EVENT_PATH", filepath.Join("testdata", "events", tt.eventFile))
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			tt.validate(t, Payload())
+		})
+	}
+}
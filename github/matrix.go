@@ -15,13 +15,27 @@ var (
 	MatrixSuffix = matrixSuffix()
 )
 
-func matrixSuffix() string {
+// Matrix returns the values of the current matrix job from MATRIX_JSON, as set by the bootstrap action, or nil when
+// not running in a matrix job or the values are invalid
+func Matrix() map[string]any {
+	values, _ := matrixValues()
+	return values
+}
+
+func matrixValues() (map[string]any, error) {
 	matrixJSON := config.Env("MATRIX_JSON", envFile()["MATRIX_JSON"])
 	if matrixJSON == "" {
-		return ""
+		return nil, nil
 	}
 	values := map[string]any{}
-	err := json.Unmarshal([]byte(matrixJSON), &values)
+	if err := json.Unmarshal([]byte(matrixJSON), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func matrixSuffix() string {
+	values, err := matrixValues()
 	if err != nil {
 		return "-unknown"
 	}
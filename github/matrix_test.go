@@ -46,3 +46,15 @@ func Test_matrixSuffix(t *testing.T) {
 		})
 	}
 }
+
+func Test_Matrix(t *testing.T) {
+	t.Setenv("MATRIX_JSON", "")
+	require.Equal(t, 0, len(Matrix()))
+
+	t.Setenv("MATRIX_JSON", `{"os":"linux","shard":2}`)
+	require.Equal(t, map[string]any{"os": "linux", "shard": float64(2)}, Matrix())
+
+	t.Setenv("MATRIX_JSON", `{"os"`)
+	require.Equal(t, 0, len(Matrix()))
+	require.Equal(t, "-unknown", matrixSuffix())
+}
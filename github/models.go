@@ -1,6 +1,7 @@
 package github
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -64,38 +65,141 @@ type WorkflowRun struct {
 }
 
 type PullRequest struct {
-	URL    string `json:"url,omitempty"`
-	Number int    `json:"number,omitempty"`
-	Head   Ref    `json:"head,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	HTMLURL      string    `json:"html_url,omitempty"`
+	Number       int       `json:"number,omitempty"`
+	State        string    `json:"state,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Body         string    `json:"body,omitempty"`
+	Draft        bool      `json:"draft,omitempty"`
+	Merged       bool      `json:"merged,omitempty"`
+	MergedAt     Timestamp `json:"merged_at,omitempty"`
+	MergeCommit  string    `json:"merge_commit_sha,omitempty"`
+	User         User      `json:"user,omitempty"`
+	Labels       []Label   `json:"labels,omitempty"`
+	Head         Ref       `json:"head,omitempty"`
+	Base         Ref       `json:"base,omitempty"`
+	ChangedFiles int       `json:"changed_files,omitempty"` // ChangedFiles is the count of files changed, see Event.ChangedFiles
 }
 
 type Ref struct {
-	Ref string `json:"ref,omitempty"`
-	SHA string `json:"sha,omitempty"`
+	Label string `json:"label,omitempty"`
+	Ref   string `json:"ref,omitempty"`
+	SHA   string `json:"sha,omitempty"`
+}
+
+type Label struct {
+	ID          int64  `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 type HeadCommit struct {
 	ID string `json:"id,omitempty"`
 }
 
+// Commit is a commit as included in push events
+type Commit struct {
+	ID        string       `json:"id,omitempty"`
+	TreeID    string       `json:"tree_id,omitempty"`
+	Distinct  bool         `json:"distinct,omitempty"`
+	Message   string       `json:"message,omitempty"`
+	Timestamp Timestamp    `json:"timestamp,omitempty"`
+	URL       string       `json:"url,omitempty"`
+	Author    CommitAuthor `json:"author,omitempty"`
+	Committer CommitAuthor `json:"committer,omitempty"`
+	Added     []string     `json:"added,omitempty"`
+	Removed   []string     `json:"removed,omitempty"`
+	Modified  []string     `json:"modified,omitempty"`
+}
+
+type CommitAuthor struct {
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type Release struct {
+	ID              int64     `json:"id,omitempty"`
+	TagName         string    `json:"tag_name,omitempty"`
+	TargetCommitish string    `json:"target_commitish,omitempty"`
+	Name            string    `json:"name,omitempty"`
+	Body            string    `json:"body,omitempty"`
+	Draft           bool      `json:"draft,omitempty"`
+	Prerelease      bool      `json:"prerelease,omitempty"`
+	URL             string    `json:"url,omitempty"`
+	HTMLURL         string    `json:"html_url,omitempty"`
+	CreatedAt       Timestamp `json:"created_at,omitempty"`
+	PublishedAt     Timestamp `json:"published_at,omitempty"`
+	Author          User      `json:"author,omitempty"`
+}
+
+// CombinedStatus is the combined state of the commit statuses for a ref: success, pending or failure
+type CombinedStatus struct {
+	State    string         `json:"state,omitempty"`
+	SHA      string         `json:"sha,omitempty"`
+	Statuses []CommitStatus `json:"statuses,omitempty"`
+}
+
+type CommitStatus struct {
+	Context     string `json:"context,omitempty"`
+	State       string `json:"state,omitempty"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+type CheckRunList struct {
+	TotalCount int64      `json:"total_count,omitempty"`
+	CheckRuns  []CheckRun `json:"check_runs,omitempty"`
+}
+
+// CheckRun is a check run for a commit, such as a GitHub Actions job; Conclusion is set when the Status is completed
+type CheckRun struct {
+	ID         int64  `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Conclusion string `json:"conclusion,omitempty"`
+	HTMLURL    string `json:"html_url,omitempty"`
+}
+
+// MergeGroup is the merge queue group included in merge_group events
+type MergeGroup struct {
+	HeadSHA    string `json:"head_sha,omitempty"`
+	HeadRef    string `json:"head_ref,omitempty"`
+	BaseSHA    string `json:"base_sha,omitempty"`
+	BaseRef    string `json:"base_ref,omitempty"`
+	HeadCommit Commit `json:"head_commit,omitempty"`
+}
+
 type Repository struct {
-	ID          int64  `json:"id"`
-	NodeID      string `json:"node_id"`
-	Name        string `json:"name"`
-	FullName    string `json:"full_name"`
-	Private     bool   `json:"private"`
-	Owner       User   `json:"owner"`
-	HTMLURL     string `json:"html_url"`
-	Description string `json:"description"`
-	Fork        bool   `json:"fork"`
-	URL         string `json:"url"`
+	ID            int64  `json:"id"`
+	NodeID        string `json:"node_id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Private       bool   `json:"private"`
+	Owner         User   `json:"owner"`
+	HTMLURL       string `json:"html_url"`
+	Description   string `json:"description"`
+	Fork          bool   `json:"fork"`
+	URL           string `json:"url"`
+	DefaultBranch string `json:"default_branch"`
 }
 
 type Timestamp time.Time
 
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if time.Time(t).IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(time.Time(t).Format(time.RFC3339))
+}
+
 func (t *Timestamp) UnmarshalJSON(data []byte) (err error) {
 	str := string(data)
 	str = strings.Trim(str, `"'`)
+	if str == "" || str == "null" {
+		return nil
+	}
 	var ts time.Time
 	ts, err = time.Parse(time.RFC3339, str)
 	if err != nil {
@@ -12,6 +12,11 @@ func Repo(v string) Param {
 	return Param{"repo", v}
 }
 
+// Token is the token used to authenticate API requests
+func Token(v string) Param {
+	return Param{"token", v}
+}
+
 func Branch(v string) Param {
 	return Param{"branch", v}
 }
@@ -0,0 +1,521 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/color"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
+	"github.com/anchore/go-make/template"
+)
+
+const (
+	SimulatePush             = "push"
+	SimulatePullRequest      = "pull_request"
+	SimulateWorkflowDispatch = "workflow_dispatch"
+	SimulateTag              = "tag"
+
+	simulatedToken        = "simulated-token"
+	simulatedServerURL    = "https://github.com"
+	simulatedWorkflowFile = "simulated.yaml"
+	simulatedRunID        = 1000
+	zeroSHA               = "0000000000000000000000000000000000000000"
+)
+
+// Simulation describes a local stand-in for a GitHub Actions run, used to exercise CI-only tasks on a workstation.
+// Any values not set are determined from the local git clone where possible.
+type Simulation struct {
+	// Event is the type of event to simulate: push, pull_request, workflow_dispatch or tag (a push of a tag)
+	Event string
+
+	// EventFile is an optional path to a custom event payload, which is rendered as a template with values describing
+	// the simulation, e.g. {{.Repo}}, {{.SHA}}, {{.Ref}}; string values are escaped for use within JSON strings
+	EventFile string
+
+	// Repo is the full repository name, e.g. anchore/syft, defaults to the repository from go.mod
+	Repo string
+
+	// Branch is the branch being built, or the head branch of a pull request, defaults to the current branch
+	Branch string
+
+	// BaseBranch is the target branch of a pull request, defaults to main
+	BaseBranch string
+
+	// Tag is the tag being pushed for tag events, defaults to a tag pointing at HEAD or v0.0.0
+	Tag string
+
+	// SHA is the commit being built, defaults to HEAD
+	SHA string
+
+	// Actor is the login of the user triggering the event
+	Actor string
+
+	// PRNumber is the pull request number for pull_request events
+	PRNumber int
+
+	// Matrix is set as MATRIX_JSON, as the bootstrap action does for matrix jobs
+	Matrix map[string]any
+
+	// Inputs are the workflow_dispatch inputs
+	Inputs map[string]any
+
+	// Routes are additional stand-in API responses keyed by http.ServeMux pattern, e.g.
+	// "GET /repos/{owner}/{repo}/releases/latest", values are handled the same as require.Server routes
+	Routes map[string]any
+
+	// Env are additional environment variables to set for the simulation
+	Env map[string]string
+}
+
+// StandInAPI is a local server standing in for the GitHub API during a simulation
+type StandInAPI struct {
+	URL      string
+	server   *httptest.Server
+	lock     sync.Mutex
+	requests []string
+}
+
+// Requests returns the method and path of all requests received, e.g. "GET /repos/owner/repo/actions/runs"
+func (s *StandInAPI) Requests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Simulate executes fn with the environment set up as a GitHub Actions runner would for the simulated event,
+// with GITHUB_API_URL pointing to a local stand-in API server; the environment is restored afterward
+func Simulate(sim Simulation, fn func(api *StandInAPI)) {
+	api, restore := sim.start()
+	defer restore()
+	fn(api)
+}
+
+// SimulateTask sets up a simulated GitHub Actions environment for all subsequent tasks, e.g.:
+// make github:simulate:pull-request static-analysis
+// this task is opt-in and not part of any default task set, add it to a Makefile to make it available
+func SimulateTask() Task {
+	simulateTask := func(event string) func() {
+		return func() {
+			_, restore := Simulation{Event: event}.start()
+			config.OnExit(restore)
+		}
+	}
+	return Task{
+		Name:        "github:simulate",
+		Description: "simulate a GitHub Actions event for subsequent tasks (GITHUB_SIMULATE_EVENT=push)",
+		Run: func() {
+			simulateTask(config.Env("GITHUB_SIMULATE_EVENT", SimulatePush))()
+		},
+		Tasks: []Task{
+			{
+				Name: "github:simulate:push",
+				Run:  simulateTask(SimulatePush),
+			},
+			{
+				Name: "github:simulate:pull-request",
+				Run:  simulateTask(SimulatePullRequest),
+			},
+			{
+				Name: "github:simulate:workflow-dispatch",
+				Run:  simulateTask(SimulateWorkflowDispatch),
+			},
+			{
+				Name: "github:simulate:tag",
+				Run:  simulateTask(SimulateTag),
+			},
+		},
+	}
+}
+
+func (s Simulation) start() (*StandInAPI, func()) {
+	s = s.withDefaults()
+
+	tmpDir := lang.Return(os.MkdirTemp(config.TmpDir, "github-simulate-"))
+	api := s.startAPI()
+
+	eventPath := filepath.Join(tmpDir, "event.json")
+	file.Write(eventPath, s.eventPayload(api.URL))
+
+	env := s.environment(api.URL, eventPath, tmpDir)
+	restoreEnv := setEnv(env)
+
+	origCI := config.CI
+	origMatrixSuffix := MatrixSuffix
+	config.CI = true
+	MatrixSuffix = matrixSuffix()
+
+	log.Info("simulating GitHub %s event for %s at %s, stand-in API: %s", color.Bold(s.Event), s.Repo, s.SHA, api.URL)
+	log.Debug("simulated environment: %v", env)
+
+	return api, func() {
+		api.server.Close()
+		restoreEnv()
+		config.CI = origCI
+		MatrixSuffix = origMatrixSuffix
+		log.Error(os.RemoveAll(tmpDir))
+	}
+}
+
+func (s Simulation) withDefaults() Simulation {
+	s.Event = lang.Default(s.Event, SimulatePush)
+	s.Repo = lang.Default(s.Repo, localRepo())
+	s.Branch = lang.Default(s.Branch, orDefault(git.Branch()), "main")
+	s.BaseBranch = lang.Default(s.BaseBranch, "main")
+	s.SHA = lang.Default(s.SHA, orDefault(git.RevParse("HEAD")), zeroSHA)
+	s.Actor = lang.Default(s.Actor, "simulated-actor")
+	s.PRNumber = lang.Default(s.PRNumber, 1)
+	if s.Event == SimulateTag && s.Tag == "" {
+		tags := orDefault(git.TagsAtHead())
+		s.Tag = lang.Default(append(tags, "v0.0.0")...)
+	}
+	return s
+}
+
+func (s Simulation) eventName() string {
+	if s.Event == SimulateTag {
+		return SimulatePush
+	}
+	return s.Event
+}
+
+func (s Simulation) ref() (ref, refName, refType string) {
+	switch s.Event {
+	case SimulateTag:
+		return "refs/tags/" + s.Tag, s.Tag, "tag"
+	case SimulatePullRequest:
+		return fmt.Sprintf("refs/pull/%v/merge", s.PRNumber), fmt.Sprintf("%v/merge", s.PRNumber), "branch"
+	}
+	return "refs/heads/" + s.Branch, s.Branch, "branch"
+}
+
+// eventPayload returns the JSON event payload GitHub would provide for the simulated event, or the rendered EventFile
+func (s Simulation) eventPayload(apiURL string) string {
+	if s.EventFile != "" {
+		return template.Render(file.Read(s.EventFile), s.templateValues(apiURL))
+	}
+
+	ref, _, _ := s.ref()
+	owner, name, _ := strings.Cut(s.Repo, "/")
+	before, changedFiles := s.commitRange()
+	sender := User{Login: s.Actor, Type: "User"}
+	payload := map[string]any{
+		"repository": Repository{
+			ID:       1,
+			Name:     name,
+			FullName: s.Repo,
+			Owner:    User{Login: owner, Type: "Organization"},
+			HTMLURL:  simulatedServerURL + "/" + s.Repo,
+			URL:      apiURL + "/repos/" + s.Repo,
+		},
+		"sender": sender,
+	}
+
+	switch s.Event {
+	case SimulatePush, SimulateTag:
+		author := CommitAuthor{Name: s.Actor, Email: s.Actor + "@users.noreply.github.com", Username: s.Actor}
+		commit := Commit{
+			ID:        s.SHA,
+			TreeID:    s.SHA,
+			Distinct:  true,
+			Message:   "simulated commit",
+			Timestamp: Timestamp(time.Now().UTC()),
+			URL:       fmt.Sprintf("%s/%s/commit/%s", simulatedServerURL, s.Repo, s.SHA),
+			Author:    author,
+			Committer: author,
+			Modified:  changedFiles,
+		}
+		var baseRef any
+		if s.Event == SimulateTag {
+			baseRef = "refs/heads/" + s.BaseBranch
+		}
+		payload["ref"] = ref
+		payload["before"] = before
+		payload["after"] = s.SHA
+		payload["created"] = s.Event == SimulateTag
+		payload["deleted"] = false
+		payload["forced"] = false
+		payload["base_ref"] = baseRef
+		payload["compare"] = fmt.Sprintf("%s/%s/compare/%s...%s", simulatedServerURL, s.Repo, before, s.SHA)
+		payload["commits"] = []Commit{commit}
+		payload["head_commit"] = commit
+		payload["pusher"] = CommitAuthor{Name: author.Name, Email: author.Email}
+	case SimulatePullRequest:
+		payload["action"] = "synchronize"
+		payload["number"] = s.PRNumber
+		payload["before"] = before
+		payload["after"] = s.SHA
+		payload["pull_request"] = PullRequest{
+			URL:     fmt.Sprintf("%s/repos/%s/pulls/%v", apiURL, s.Repo, s.PRNumber),
+			HTMLURL: fmt.Sprintf("%s/%s/pull/%v", simulatedServerURL, s.Repo, s.PRNumber),
+			Number:  s.PRNumber,
+			State:   "open",
+			Title:   "simulated pull request",
+			User:    sender,
+			Head:    Ref{Ref: s.Branch, SHA: s.SHA},
+			Base:    Ref{Ref: s.BaseBranch, SHA: before},
+		}
+	case SimulateWorkflowDispatch:
+		payload["inputs"] = s.inputs()
+		payload["ref"] = ref
+		payload["workflow"] = ".github/workflows/" + simulatedWorkflowFile
+	default:
+		panic(fmt.Errorf("unsupported event to simulate: %s", s.Event))
+	}
+	return toJSON(payload)
+}
+
+// templateValues returns the values available to a custom EventFile, strings are escaped for use within JSON strings
+// while BaseRef, Created, PRNumber, ChangedFiles and Inputs are JSON values
+func (s Simulation) templateValues(apiURL string) map[string]any {
+	ref, _, _ := s.ref()
+	owner, name, _ := strings.Cut(s.Repo, "/")
+	baseRef := "null"
+	if s.Event == SimulateTag {
+		baseRef = toJSON("refs/heads/" + s.BaseBranch)
+	}
+	before, changedFiles := s.commitRange()
+	return map[string]any{
+		"Ref":          jsonString(ref),
+		"Repo":         jsonString(s.Repo),
+		"Owner":        jsonString(owner),
+		"Name":         jsonString(name),
+		"SHA":          jsonString(s.SHA),
+		"Before":       jsonString(before),
+		"Branch":       jsonString(s.Branch),
+		"BaseBranch":   jsonString(s.BaseBranch),
+		"BaseRef":      baseRef,
+		"Created":      s.Event == SimulateTag,
+		"Actor":        jsonString(s.Actor),
+		"PRNumber":     s.PRNumber,
+		"Timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"ChangedFiles": toJSON(append([]string{}, changedFiles...)),
+		"Inputs":       toJSON(s.inputs()),
+		"WorkflowFile": simulatedWorkflowFile,
+		"ServerURL":    simulatedServerURL,
+		"ApiURL":       jsonString(apiURL),
+	}
+}
+
+// commitRange returns the parent of the simulated commit and the files it changed, where these can be determined
+func (s Simulation) commitRange() (before string, changedFiles []string) {
+	before = lang.Default(orDefault(git.RevParse(s.SHA+"~1")), zeroSHA)
+	return before, orDefault(git.ChangedFiles(before, s.SHA))
+}
+
+func (s Simulation) inputs() map[string]any {
+	if s.Inputs == nil {
+		return map[string]any{}
+	}
+	return s.Inputs
+}
+
+func (s Simulation) environment(apiURL, eventPath, tmpDir string) map[string]string {
+	ref, refName, refType := s.ref()
+	owner, _, _ := strings.Cut(s.Repo, "/")
+	headRef, baseRef := "", ""
+	if s.Event == SimulatePullRequest {
+		headRef, baseRef = s.Branch, s.BaseBranch
+	}
+	matrixJSON := ""
+	if len(s.Matrix) > 0 {
+		matrixJSON = toJSON(s.Matrix)
+	}
+	env := map[string]string{
+		"CI":                      "true",
+		"GITHUB_ACTIONS":          "true",
+		"GITHUB_API_URL":          apiURL,
+		"GITHUB_SERVER_URL":       simulatedServerURL,
+		"GITHUB_TOKEN":            simulatedToken,
+		"GITHUB_EVENT_NAME":       s.eventName(),
+		"GITHUB_EVENT_PATH":       eventPath,
+		"GITHUB_REF":              ref,
+		"GITHUB_REF_NAME":         refName,
+		"GITHUB_REF_TYPE":         refType,
+		"GITHUB_HEAD_REF":         headRef,
+		"GITHUB_BASE_REF":         baseRef,
+		"GITHUB_SHA":              s.SHA,
+		"GITHUB_REPOSITORY":       s.Repo,
+		"GITHUB_REPOSITORY_OWNER": owner,
+		"GITHUB_ACTOR":            s.Actor,
+		"GITHUB_WORKFLOW":         "Simulated",
+		"GITHUB_RUN_ID":           strconv.Itoa(simulatedRunID),
+		"GITHUB_RUN_NUMBER":       "1",
+		"GITHUB_RUN_ATTEMPT":      "1",
+		"GITHUB_JOB":              "simulated",
+		"RUNNER_OS":               runnerOS(),
+		"RUNNER_ARCH":             strings.ToUpper(runtime.GOARCH),
+		"RUNNER_TEMP":             tmpDir,
+		"MATRIX_JSON":             matrixJSON,
+	}
+	for k, v := range s.Env {
+		env[k] = v
+	}
+	return env
+}
+
+func (s Simulation) startAPI() *StandInAPI {
+	api := &StandInAPI{}
+	mux := http.NewServeMux()
+
+	routes := map[string]any{
+		"GET /repos/{owner}/{repo}/actions/runs": WorkflowRunList{
+			TotalCount: 1,
+			WorkflowRuns: []WorkflowRun{{
+				ID:         simulatedRunID,
+				Name:       "Simulated",
+				HeadBranch: s.Branch,
+				HeadSHA:    s.SHA,
+				Event:      s.eventName(),
+				Status:     "completed",
+				Conclusion: "success",
+			}},
+		},
+		"GET /repos/{owner}/{repo}/actions/runs/{id}/artifacts": ArtifactList{},
+		"DELETE /repos/{owner}/{repo}/actions/artifacts/{id}": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"GET /repos/{owner}/{repo}/pulls/{number}": PullRequest{
+			Number: s.PRNumber,
+			Head:   Ref{Ref: s.Branch, SHA: s.SHA},
+		},
+		"POST /repos/{owner}/{repo}/issues/{number}/comments": func(w http.ResponseWriter, r *http.Request) {
+			body := lang.Continue(io.ReadAll(r.Body))
+			log.Info(color.Grey("stand-in API comment: %s"), string(body))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			lang.Continue(w.Write([]byte(`{"id":1}`)))
+		},
+		"/": func(w http.ResponseWriter, r *http.Request) {
+			log.Warn("stand-in API: no response for %s %s", r.Method, r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			lang.Continue(w.Write([]byte(`{"message":"Not Found"}`)))
+		},
+	}
+	for pattern, response := range s.Routes {
+		routes[pattern] = response
+	}
+	for pattern, response := range routes {
+		mux.HandleFunc(pattern, standInHandler(response))
+	}
+
+	api.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("stand-in API: %s %s", r.Method, r.URL.RequestURI())
+		api.lock.Lock()
+		api.requests = append(api.requests, r.Method+" "+r.URL.Path)
+		api.lock.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	api.URL = api.server.URL
+	return api
+}
+
+func standInHandler(response any) http.HandlerFunc {
+	switch response := response.(type) {
+	case http.HandlerFunc:
+		return response
+	case func(http.ResponseWriter, *http.Request):
+		return response
+	case []byte:
+		return func(w http.ResponseWriter, _ *http.Request) {
+			lang.Continue(w.Write(response))
+		}
+	case string:
+		return func(w http.ResponseWriter, _ *http.Request) {
+			lang.Continue(w.Write([]byte(response)))
+		}
+	}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		log.Error(json.NewEncoder(w).Encode(response))
+	}
+}
+
+// setEnv sets all the provided environment variables, returning a function to restore the previous values
+func setEnv(env map[string]string) func() {
+	type prior struct {
+		value string
+		set   bool
+	}
+	priors := map[string]prior{}
+	for k, v := range env {
+		value, set := os.LookupEnv(k)
+		priors[k] = prior{value, set}
+		lang.Throw(os.Setenv(k, v))
+	}
+	redact.Refresh()
+	return func() {
+		for k, p := range priors {
+			if p.set {
+				log.Error(os.Setenv(k, p.value))
+			} else {
+				log.Error(os.Unsetenv(k))
+			}
+		}
+		redact.Refresh()
+	}
+}
+
+// localRepo returns the GitHub repository based on the go.mod module path, or the root directory name
+func localRepo() string {
+	var modulePath string
+	log.Error(lang.Catch(func() {
+		if f := gomod.Read(); f != nil && f.Module != nil {
+			modulePath = f.Module.Mod.Path
+		}
+	}))
+	if strings.HasPrefix(modulePath, "github.com/") {
+		repo := strings.TrimPrefix(modulePath, "github.com/")
+		repo = regexp.MustCompile(`([^/]+/[^/]+)/.*`).ReplaceAllString(repo, "$1")
+		if strings.Count(repo, "/") == 1 {
+			return repo
+		}
+	}
+	return "local/" + filepath.Base(RootDir())
+}
+
+// orDefault returns the value, or the zero value when an error occurs
+func orDefault[T any](value T, err error) T {
+	if err != nil {
+		log.Debug("simulation default: %v", err)
+		var zero T
+		return zero
+	}
+	return value
+}
+
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS"
+	case "windows":
+		return "Windows"
+	}
+	return "Linux"
+}
+
+func toJSON(value any) string {
+	return string(lang.Return(json.Marshal(value)))
+}
+
+// jsonString returns the value escaped for use within a JSON string, without the surrounding quotes
+func jsonString(value string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(toJSON(value), `"`), `"`)
+}
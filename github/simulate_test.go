@@ -0,0 +1,154 @@
+package github
+
+import (
+	"os"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Simulate(t *testing.T) {
+	tests := []struct {
+		name     string
+		sim      Simulation
+		validate func(t *testing.T, p Event)
+	}{
+		{
+			name: "push",
+			sim: Simulation{
+				Event:  SimulatePush,
+				Branch: "some-branch",
+				SHA:    "1234567890abcdef1234567890abcdef12345678",
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, "push", p.Type)
+				require.Equal(t, "refs/heads/some-branch", p.Ref)
+				require.Equal(t, "1234567890abcdef1234567890abcdef12345678", p.SHA)
+				require.True(t, !p.IsPullRequest())
+			},
+		},
+		{
+			name: "pull request",
+			sim: Simulation{
+				Event:    SimulatePullRequest,
+				Branch:   "feature",
+				PRNumber: 42,
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, "pull_request", p.Type)
+				require.Equal(t, "refs/pull/42/merge", p.Ref)
+				require.True(t, p.IsPullRequest())
+				require.Equal(t, 42, p.PullRequest.Number)
+				require.Equal(t, "feature", p.PullRequest.Head.Ref)
+				require.Equal(t, "feature", os.Getenv("GITHUB_HEAD_REF"))
+			},
+		},
+		{
+			name: "tag",
+			sim: Simulation{
+				Event: SimulateTag,
+				Tag:   "v1.2.3",
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, "push", p.Type)
+				require.Equal(t, "refs/tags/v1.2.3", p.Ref)
+				require.Equal(t, "tag", os.Getenv("GITHUB_REF_TYPE"))
+			},
+		},
+		{
+			name: "values requiring escaping",
+			sim: Simulation{
+				Event: SimulatePush,
+				Actor: `some "quoted" \ actor`,
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, `some "quoted" \ actor`, p.Sender.Login)
+				require.Equal(t, `some "quoted" \ actor`, p.HeadCommit.Author.Name)
+				require.Equal(t, "testorg/testrepo", p.Repository.FullName)
+			},
+		},
+		{
+			name: "custom event file",
+			sim: Simulation{
+				Event:     SimulatePullRequest,
+				EventFile: "testdata/simulate_event.json",
+				Branch:    `feature/"quoted"`,
+				PRNumber:  7,
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, 7, p.PullRequest.Number)
+				require.Equal(t, `feature/"quoted"`, p.PullRequest.Head.Ref)
+				require.Equal(t, "testorg/testrepo", p.Repository.FullName)
+			},
+		},
+		{
+			name: "workflow dispatch with matrix",
+			sim: Simulation{
+				Event:  SimulateWorkflowDispatch,
+				Matrix: map[string]any{"os": "windows"},
+				Inputs: map[string]any{"version": "v1.0.0"},
+			},
+			validate: func(t *testing.T, p Event) {
+				require.Equal(t, "workflow_dispatch", p.Type)
+				require.Equal(t, "-windows", MatrixSuffix)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer require.Test(t)
+
+			tt.sim.Repo = "testorg/testrepo"
+			Simulate(tt.sim, func(api *StandInAPI) {
+				require.True(t, config.CI)
+				require.Equal(t, api.URL, os.Getenv("GITHUB_API_URL"))
+
+				p := Payload()
+				require.Equal(t, "testorg/testrepo", p.Repo)
+				require.Equal(t, "testorg", p.Owner)
+				require.Equal(t, simulatedToken, p.Token)
+				tt.validate(t, p)
+			})
+		})
+	}
+
+	require.Equal(t, "", MatrixSuffix)
+}
+
+func Test_SimulateStandInAPI(t *testing.T) {
+	defer require.Test(t)
+
+	sim := Simulation{
+		Repo:   "testorg/testrepo",
+		Branch: "main",
+		Routes: map[string]any{
+			"GET /repos/{owner}/{repo}/actions/runs/{id}/artifacts": ArtifactList{
+				TotalCount: 1,
+				Artifacts:  []Artifact{{ID: 5, Name: "code-coverage"}},
+			},
+		},
+	}
+
+	Simulate(sim, func(api *StandInAPI) {
+		client := Api{
+			Token:   simulatedToken,
+			BaseURL: api.URL,
+			Repo:    "testorg/testrepo",
+		}
+
+		artifacts, err := client.ListArtifactsForBranch("main", "Simulated", "code-*")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(artifacts))
+		require.Equal(t, "code-coverage", artifacts[0].Name)
+
+		require.NoError(t, client.DeleteArtifact(5))
+
+		require.EqualElements(t, []string{
+			"GET /repos/testorg/testrepo/actions/runs",
+			"GET /repos/testorg/testrepo/actions/runs/1000/artifacts",
+			"DELETE /repos/testorg/testrepo/actions/artifacts/5",
+		}, api.Requests())
+	})
+}
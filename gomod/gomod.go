@@ -1,7 +1,9 @@
 package gomod
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"golang.org/x/mod/modfile"
 
@@ -10,6 +12,15 @@ import (
 	"github.com/anchore/go-make/lang"
 )
 
+// Root returns the directory containing the nearest go.mod, e.g. the module root in a multi-module repository
+func Root() string {
+	modFile := file.FindParent(file.Cwd(), "go.mod")
+	if modFile == "" {
+		panic(fmt.Errorf("go.mod not found"))
+	}
+	return filepath.Dir(modFile)
+}
+
 // Read reads the first go.mod found
 func Read() *modfile.File {
 	modFile := file.FindParent(file.Cwd(), "go.mod")
@@ -66,11 +66,14 @@ func HandleErrors() {
 		errText := strings.TrimSpace(fmt.Sprintf("ERROR: %v", v.Err))
 		log.Info("\n" + formatError(errText) + "\n\n" + strings.TrimSpace(v.Log) + "\n\n" + color.Grey("\n\n"+strings.Join(v.Stack, "\n")))
 		if v.ExitCode > 0 {
+			// os.Exit does not run deferred functions, so cleanup must happen first
+			config.DoExit()
 			os.Exit(v.ExitCode)
 		}
 	default:
 		log.Info(formatError("ERROR: %v", v) + color.Grey("\n"+strings.Join(stackTraceLines(), "\n")))
 	}
+	config.DoExit()
 	os.Exit(1)
 }
 
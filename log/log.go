@@ -6,6 +6,7 @@ import (
 
 	"github.com/anchore/go-make/color"
 	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/redact"
 	"github.com/anchore/go-make/template"
 )
 
@@ -13,9 +14,9 @@ var Prefix = ""
 
 var Info = func(format string, args ...any) {
 	if len(args) == 0 {
-		_, _ = os.Stderr.WriteString(Prefix + template.Render(format) + "\n")
+		write(Prefix + template.Render(format) + "\n")
 	} else {
-		_, _ = fmt.Fprintf(os.Stderr, Prefix+template.Render(format)+"\n", args...)
+		write(fmt.Sprintf(Prefix+template.Render(format)+"\n", args...))
 	}
 }
 
@@ -48,9 +49,14 @@ func init() {
 }
 
 func debugLogf(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stderr, Prefix+color.Grey(template.Render(format))+"\n", args...)
+	write(fmt.Sprintf(Prefix+color.Grey(template.Render(format))+"\n", args...))
 }
 
 func traceLogf(format string, args ...any) {
-	_, _ = fmt.Fprintf(os.Stderr, Prefix+color.Grey(template.Render(format))+"\n", args...)
+	write(fmt.Sprintf(Prefix+color.Grey(template.Render(format))+"\n", args...))
+}
+
+// write outputs to stderr with any secrets redacted
+func write(text string) {
+	_, _ = os.Stderr.WriteString(redact.Apply(text))
 }
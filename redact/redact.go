@@ -0,0 +1,103 @@
+package redact
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Replacement is the text secret values are replaced with
+const Replacement = "***"
+
+// MinLength is the minimum length of values to redact; shorter values such as "1" or "true" would mangle output
+const MinLength = 6
+
+// EnvPatterns are substrings of environment variable names whose values are automatically redacted
+var EnvPatterns = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "PRIVATE_KEY", "API_KEY", "ACCESS_KEY", "CREDENTIAL"}
+
+var (
+	lock    sync.RWMutex
+	secrets []string
+	// sorted are the registered and environment secrets, longest first, or nil when they need to be determined again
+	sorted []string
+)
+
+// Add registers secret values to be redacted from all output
+func Add(values ...string) {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < MinLength || slices.Contains(secrets, v) {
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+	sorted = nil
+}
+
+// Refresh reads secret-named environment variables again; the environment is otherwise only read the first time
+// output is redacted after secrets are registered, so this must be called after setting secret environment variables
+func Refresh() {
+	lock.Lock()
+	defer lock.Unlock()
+	sorted = nil
+}
+
+// Apply returns the text with all registered secrets and values of secret-named environment variables replaced
+func Apply(text string) string {
+	if text == "" {
+		return text
+	}
+	for _, v := range sortedSecrets() {
+		text = strings.ReplaceAll(text, v, Replacement)
+	}
+	return text
+}
+
+// sortedSecrets returns the registered and environment secrets, longest first, reading the environment if needed
+func sortedSecrets() []string {
+	lock.RLock()
+	out := sorted
+	lock.RUnlock()
+	if out != nil {
+		return out
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if sorted == nil {
+		out = append(envSecrets(), secrets...)
+		// replace longer values first, in case one secret contains another
+		slices.SortFunc(out, func(a, b string) int {
+			return len(b) - len(a)
+		})
+		sorted = append([]string{}, out...)
+	}
+	return sorted
+}
+
+// envSecrets returns values of environment variables matching the EnvPatterns
+func envSecrets() []string {
+	var out []string
+	for _, entry := range os.Environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || len(value) < MinLength || !IsSecretName(name) {
+			continue
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// IsSecretName indicates the environment variable name matches one of the EnvPatterns
+func IsSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range EnvPatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package redact_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/anchore/go-make/redact"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Apply(t *testing.T) {
+	t.Setenv("MY_SERVICE_TOKEN", "env-token-value")
+	t.Setenv("SOME_API_KEY", "env-api-key")
+	t.Setenv("NOT_SENSITIVE", "visible-value")
+	t.Setenv("SHORT_SECRET", "1")
+
+	Add("registered-secret", "registered-secret-longer", "abc", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "token: env-token-value, key: env-api-key",
+			expected: "token: ***, key: ***",
+		},
+		{
+			input:    "visible-value 1 abc",
+			expected: "visible-value 1 abc",
+		},
+		{
+			input:    "a registered-secret and a registered-secret-longer",
+			expected: "a *** and a ***",
+		},
+		{
+			input:    "",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, Apply(tt.input))
+		})
+	}
+}
+
+func Test_Refresh(t *testing.T) {
+	Add("refresh-registered-secret")
+	require.Equal(t, "a ***", Apply("a refresh-registered-secret"))
+
+	// the environment is read when output is first redacted, and again after Refresh
+	t.Setenv("LATER_SERVICE_TOKEN", "later-token-value")
+	require.Equal(t, "later-token-value", Apply("later-token-value"))
+	Refresh()
+	require.Equal(t, "***", Apply("later-token-value"))
+}
+
+func Test_Writer(t *testing.T) {
+	Add("writer-secret-value")
+
+	buf := bytes.Buffer{}
+	w := Writer(&buf)
+
+	// partial output is written immediately
+	_, err := w.Write([]byte("Enter value: "))
+	require.NoError(t, err)
+	require.Equal(t, "Enter value: ", buf.String())
+
+	// a secret split across writes is held until it can be redacted
+	_, err = w.Write([]byte("a writer-sec"))
+	require.NoError(t, err)
+	require.Equal(t, "Enter value: a ", buf.String())
+	_, err = w.Write([]byte("ret-value\r50%"))
+	require.NoError(t, err)
+	require.Equal(t, "Enter value: a ***\r50%", buf.String())
+
+	// held text is written on close
+	_, err = w.Write([]byte(" writer-"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, "Enter value: a ***\r50% writer-", buf.String())
+}
+
+func Test_IsSecretName(t *testing.T) {
+	require.True(t, IsSecretName("GITHUB_TOKEN"))
+	require.True(t, IsSecretName("ACTIONS_RUNTIME_TOKEN"))
+	require.True(t, IsSecretName("db_password"))
+	require.True(t, IsSecretName("AWS_SECRET_ACCESS_KEY"))
+	require.True(t, !IsSecretName("GITHUB_REPOSITORY"))
+	require.True(t, !IsSecretName("KEYBOARD"))
+}
@@ -0,0 +1,55 @@
+package redact
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Writer creates a writer which writes to w with secrets redacted. Output is written as it is received, except trailing
+// text which could be the start of a secret, which is held until more is written; Close must be called to write it
+func Writer(w io.Writer) io.WriteCloser {
+	return &writer{w: w}
+}
+
+type writer struct {
+	lock    sync.Mutex
+	w       io.Writer
+	pending string
+}
+
+func (r *writer) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	values := sortedSecrets()
+	text := r.pending + string(p)
+	for _, v := range values {
+		text = strings.ReplaceAll(text, v, Replacement)
+	}
+	// hold back the longest suffix which may be completed to a secret by the next write
+	held := 0
+	for _, v := range values {
+		for n := min(len(v)-1, len(text)); n > held; n-- {
+			if strings.HasSuffix(text, v[:n]) {
+				held = n
+				break
+			}
+		}
+	}
+	r.pending = text[len(text)-held:]
+	_, err := io.WriteString(r.w, text[:len(text)-held])
+	return len(p), err
+}
+
+func (r *writer) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pending == "" {
+		return nil
+	}
+	_, err := io.WriteString(r.w, Apply(r.pending))
+	r.pending = ""
+	return err
+}
@@ -0,0 +1,47 @@
+package require
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GitRepo creates a git repository in a new temporary directory with an initial commit on the main branch,
+// returning the directory; the git author and committer are set in the environment for the test
+func GitRepo(t *testing.T) string {
+	t.Helper()
+	for _, prefix := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(prefix+"_NAME", "Test User")
+		t.Setenv(prefix+"_EMAIL", "test@example.com")
+	}
+	dir := t.TempDir()
+	Git(t, dir, "init", "--initial-branch=main")
+	GitCommit(t, dir, "initial commit", map[string]string{"README.md": "# test\n"})
+	return dir
+}
+
+// Git executes git with the provided args in the directory, returning the trimmed output
+func Git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GitCommit writes the files, relative to the directory, and commits all changes with the message
+func GitCommit(t *testing.T, dir, message string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+	Git(t, dir, "add", "-A")
+	Git(t, dir, "commit", "--allow-empty", "-m", message)
+}
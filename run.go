@@ -26,6 +26,20 @@ func Run(cmd string, args ...run.Option) string {
 	return lang.Return(run.Command(cmd, args...))
 }
 
+// Start executes a shell.Split command in the background like Run, returning once any readiness probes succeed,
+// see run.Start
+func Start(cmd string, args ...run.Option) *run.Process {
+	cmdParts := parseCmd(cmd)
+	if len(cmdParts) > 1 {
+		args = append([]run.Option{run.Args(cmdParts[1:]...)}, args...)
+	}
+	cmd = binny.ManagedToolPath(cmdParts[0])
+	if cmd == "" {
+		cmd = cmdParts[0]
+	}
+	return lang.Return(run.Start(cmd, args...))
+}
+
 func parseCmd(cmd ...string) []string {
 	cmd = append(shell.Split(cmd[0]), cmd[1:]...)
 	for i := range cmd {
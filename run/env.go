@@ -0,0 +1,131 @@
+package run
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/anchore/go-make/color"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/log"
+)
+
+var (
+	// DefaultDropEnv are patterns of environment variables not passed to commands by default. It causes problems to
+	// keep go environment variables in embedded go executions, e.g. GOROOT or GOTOOLCHAIN of the go-make process
+	// applying to a different module, so these are removed unless matched by DefaultInheritEnv or InheritEnv.
+	DefaultDropEnv = []string{"GO*", "CGO_*"}
+
+	// DefaultInheritEnv are patterns of environment variables passed to commands, even if matched by DefaultDropEnv;
+	// these configure access to modules, caches and build behavior rather than the go installation, see
+	// `go help environment`. GOTOOLCHAIN is not included, since go sets it when switching toolchains.
+	DefaultInheritEnv = []string{
+		"GOPRIVATE", "GONOPROXY", "GONOSUMDB", "GOPROXY", "GOSUMDB", "GOINSECURE", "GOAUTH", "GOVCS",
+		"GOCACHE", "GOMODCACHE", "GOFLAGS", "GOEXPERIMENT",
+	}
+)
+
+// CleanEnv starts the command with an empty environment, rather than inheriting the current environment; use
+// InheritEnv to pass specific variables such as PATH and HOME, and Env to set others
+func CleanEnv() Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.cleanEnv = true
+		}
+		return nil
+	}
+}
+
+// InheritEnv passes environment variables matching the path.Match patterns to the command, e.g. "GOPRIVATE" or
+// "AWS_*", even if they would otherwise be dropped
+func InheritEnv(patterns ...string) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.inherit = append(cfg.inherit, patterns...)
+		}
+		return nil
+	}
+}
+
+// DropEnv does not pass environment variables matching the path.Match patterns to the command, e.g. "GITHUB_*"
+func DropEnv(patterns ...string) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.drop = append(cfg.drop, patterns...)
+		}
+		return nil
+	}
+}
+
+// composeEnv returns the inherited environment according to the configured policy followed by the explicitly set
+// entries, with only the last value of each variable retained
+func composeEnv(cfg *runConfig, explicit []string) []string {
+	var env []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if inheritEnv(cfg, name) {
+			log.Trace(color.Grey("adding environment entry: %v", entry))
+			env = append(env, entry)
+		} else {
+			log.Trace(color.Grey("dropped environment entry: %v", name))
+		}
+	}
+	return dedupeEnv(append(env, explicit...))
+}
+
+func inheritEnv(cfg *runConfig, name string) bool {
+	switch {
+	case matchesEnv(name, cfg.inherit):
+		return true
+	case cfg.cleanEnv, matchesEnv(name, cfg.drop):
+		return false
+	case matchesEnv(name, DefaultInheritEnv):
+		return true
+	}
+	return !matchesEnv(name, DefaultDropEnv)
+}
+
+func matchesEnv(name string, patterns []string) bool {
+	if config.Windows {
+		// environment variable names are case-insensitive on Windows
+		name = strings.ToUpper(name)
+	}
+	for _, pattern := range patterns {
+		if config.Windows {
+			pattern = strings.ToUpper(pattern)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeEnv removes all but the last entry for each variable, keeping the position of the first entry
+func dedupeEnv(env []string) []string {
+	key := func(entry string) string {
+		name, _, _ := strings.Cut(entry, "=")
+		if config.Windows {
+			return strings.ToUpper(name)
+		}
+		return name
+	}
+	last := map[string]string{}
+	for _, entry := range env {
+		last[key(entry)] = entry
+	}
+	out := make([]string, 0, len(last))
+	for _, entry := range env {
+		k := key(entry)
+		if v, ok := last[k]; ok {
+			out = append(out, v)
+			delete(last, k)
+		}
+	}
+	return out
+}
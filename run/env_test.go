@@ -0,0 +1,79 @@
+package run
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_CommandEnv(t *testing.T) {
+	testapp := buildTestApp(t)
+
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOMAKE_TEST_GO_VAR", "go-value")
+	t.Setenv("CGO_TEST_VAR", "cgo-value")
+	t.Setenv("RUN_TEST_VAR", "run-value")
+	t.Setenv("RUN_TEST_OTHER", "other-value")
+
+	env := func(opts ...Option) []string {
+		args := Args("env", "GOFLAGS", "env", "GOMAKE_TEST_GO_VAR", "env", "CGO_TEST_VAR", "env", "RUN_TEST_VAR", "env", "RUN_TEST_OTHER")
+		return strings.Split(Return(Command(testapp, append([]Option{args}, opts...)...)), "\n")
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "default policy",
+			expected: []string{"-mod=mod", "<unset>", "<unset>", "run-value", "other-value"},
+		},
+		{
+			name:     "inherit dropped variables",
+			opts:     List(InheritEnv("GOMAKE_TEST_*", "CGO_TEST_VAR")),
+			expected: []string{"-mod=mod", "go-value", "cgo-value", "run-value", "other-value"},
+		},
+		{
+			name:     "drop variables",
+			opts:     List(DropEnv("RUN_TEST_*", "GOFLAGS")),
+			expected: []string{"<unset>", "<unset>", "<unset>", "<unset>", "<unset>"},
+		},
+		{
+			name:     "inherit takes precedence over drop",
+			opts:     List(DropEnv("RUN_TEST_*"), InheritEnv("RUN_TEST_OTHER")),
+			expected: []string{"-mod=mod", "<unset>", "<unset>", "<unset>", "other-value"},
+		},
+		{
+			name:     "clean env",
+			opts:     List(CleanEnv(), InheritEnv("RUN_TEST_VAR"), Env("GOMAKE_TEST_GO_VAR", "explicit")),
+			expected: []string{"<unset>", "explicit", "<unset>", "run-value", "<unset>"},
+		},
+		{
+			name:     "env replaces inherited and previous values",
+			opts:     List(Env("RUN_TEST_VAR", "first"), Env("RUN_TEST_VAR", "second"), Env("GOFLAGS", "-v")),
+			expected: []string{"-v", "<unset>", "<unset>", "second", "other-value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, env(tt.opts...))
+		})
+	}
+}
+
+func Test_dedupeEnv(t *testing.T) {
+	require.Equal(t, []string{"A=3", "B=2", "C="}, dedupeEnv([]string{"A=1", "B=2", "A=3", "C="}))
+}
+
+func Test_inheritEnvDefaults(t *testing.T) {
+	cfg := &runConfig{}
+	for _, name := range []string{"GOPRIVATE", "GOSUMDB", "GOPROXY", "GOFLAGS", "GOEXPERIMENT", "GOMODCACHE", "PATH"} {
+		require.True(t, inheritEnv(cfg, name))
+	}
+	for _, name := range []string{"GOROOT", "GOTOOLCHAIN", "GOOS", "CGO_ENABLED"} {
+		require.True(t, !inheritEnv(cfg, name))
+	}
+}
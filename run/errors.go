@@ -0,0 +1,28 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrTimeout is matched by errors from commands stopped because the Timeout elapsed
+	ErrTimeout = errors.New("timed out")
+	// ErrCanceled is matched by errors from commands stopped because the run Context was cancelled
+	ErrCanceled = errors.New("canceled")
+)
+
+// SignalError is returned when a command was terminated by a signal it did not handle
+type SignalError struct {
+	Signal os.Signal
+	Err    error
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("terminated by signal %v: %v", e.Signal, e.Err)
+}
+
+func (e *SignalError) Unwrap() error {
+	return e.Err
+}
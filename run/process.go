@@ -0,0 +1,211 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/log"
+)
+
+// DefaultReadyTimeout is the maximum time Start waits for readiness probes to succeed, unless ReadyTimeout is specified
+var DefaultReadyTimeout = 30 * time.Second
+
+// Process is a command running in the background, see Start
+type Process struct {
+	cmd      *command
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stdout   string
+	err      error
+	stopOnce sync.Once
+}
+
+// Start runs a command in the background, returning once it has started and all readiness probes specified with
+// ReadyOnPort, ReadyOnHTTP or ReadyOnLog have succeeded. The process is stopped when the run context is cancelled,
+// Stop is called, or at exit via config.OnExit, including when exiting due to a panic.
+func Start(cmd string, opts ...Option) (*Process, error) {
+	ctx, cancel := context.WithCancel(Context())
+	c, err := newCommand(ctx, cmd, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p := &Process{
+		cmd:    c,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	if err = c.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		defer close(p.done)
+		p.stdout, p.err = c.result(c.Wait())
+	}()
+	config.OnExit(func() {
+		log.Error(p.Stop())
+	})
+
+	if err = p.awaitReady(); err != nil {
+		return p, errors.Join(err, p.Stop())
+	}
+	return p, nil
+}
+
+// Pid returns the process ID
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Wait waits for the process to exit, returning stdout if it was not otherwise captured
+func (p *Process) Wait() (string, error) {
+	<-p.done
+	return p.stdout, p.err
+}
+
+// Stop gracefully stops the process by sending an interrupt signal to the process group, and kills it if it does not
+// exit within the WaitDelay. An error is only returned if the process had already exited with an error.
+func (p *Process) Stop() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+	}
+	p.stopOnce.Do(func() {
+		log.Debug("stopping: %v", displayPath(p.cmd.name))
+		p.cancel()
+	})
+	<-p.done
+	return nil
+}
+
+func (p *Process) awaitReady() error {
+	if len(p.cmd.cfg.ready) == 0 {
+		return nil
+	}
+	timeout := p.cmd.cfg.readyTimeout
+	if timeout == 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(Context(), timeout)
+	defer cancel()
+
+	errs := make(chan error, len(p.cmd.cfg.ready))
+	for _, probe := range p.cmd.cfg.ready {
+		go func() {
+			errs <- probe(ctx)
+		}()
+	}
+	for range p.cmd.cfg.ready {
+		select {
+		case err := <-errs:
+			if err != nil {
+				return fmt.Errorf("waiting for '%s' to be ready: %w", p.cmd.name, err)
+			}
+		case <-p.done:
+			return fmt.Errorf("'%s' exited before ready: %w", p.cmd.name, p.err)
+		}
+	}
+	return nil
+}
+
+// ReadyTimeout sets the maximum time Start waits for readiness probes
+func ReadyTimeout(timeout time.Duration) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.readyTimeout = timeout
+		}
+		return nil
+	}
+}
+
+// ReadyOnPort is a readiness probe for Start, which succeeds once a TCP connection to the address can be made,
+// e.g. "localhost:5432"
+func ReadyOnPort(address string) Option {
+	return readyProbe(func(ctx context.Context) error {
+		return poll(ctx, func() bool {
+			conn, err := net.DialTimeout("tcp", address, time.Second)
+			if err != nil {
+				return false
+			}
+			_ = conn.Close()
+			return true
+		})
+	})
+}
+
+// ReadyOnHTTP is a readiness probe for Start, which succeeds once a GET request to the url returns 200 OK
+func ReadyOnHTTP(url string) Option {
+	return readyProbe(func(ctx context.Context) error {
+		return poll(ctx, func() bool {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return false
+			}
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return false
+			}
+			_ = rsp.Body.Close()
+			return rsp.StatusCode == http.StatusOK
+		})
+	})
+}
+
+// ReadyOnLog is a readiness probe for Start, which succeeds once a line of stdout or stderr matches the pattern
+func ReadyOnLog(pattern string) Option {
+	expr := regexp.MustCompile(pattern)
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		matched := make(chan struct{})
+		once := sync.Once{}
+		return Options(
+			OnLine(func(_, line string) {
+				if expr.MatchString(line) {
+					once.Do(func() { close(matched) })
+				}
+			}),
+			readyProbe(func(ctx context.Context) error {
+				select {
+				case <-matched:
+					return nil
+				case <-ctx.Done():
+					return fmt.Errorf("no output matched: %s: %w", pattern, ctx.Err())
+				}
+			}),
+		)(ctx, cmd)
+	}
+}
+
+func readyProbe(probe func(ctx context.Context) error) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.ready = append(cfg.ready, probe)
+		}
+		return nil
+	}
+}
+
+func poll(ctx context.Context, ready func() bool) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for !ready() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Start(t *testing.T) {
+	testapp := buildTestApp(t)
+
+	t.Run("wait returns stdout", func(t *testing.T) {
+		p, err := Start(testapp, Args("stdout", "some-value"))
+		require.NoError(t, err)
+		out, err := p.Wait()
+		require.NoError(t, err)
+		require.Equal(t, "some-value", out)
+		require.NoError(t, p.Stop())
+	})
+
+	t.Run("ready on port and http", func(t *testing.T) {
+		addr := freeAddress(t)
+		p, err := Start(testapp, Args("serve", addr), ReadyOnPort(addr), ReadyOnHTTP("http://"+addr+"/"))
+		require.NoError(t, err)
+
+		rsp, err := http.Get("http://" + addr + "/")
+		require.NoError(t, err)
+		require.NoError(t, rsp.Body.Close())
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+		require.NoError(t, p.Stop())
+		_, err = net.DialTimeout("tcp", addr, time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("ready on log", func(t *testing.T) {
+		p, err := Start(testapp, Args("serve", "127.0.0.1:0"), ReadyOnLog(`listening on 127\.0\.0\.1:\d+`))
+		require.NoError(t, err)
+		require.NoError(t, p.Stop())
+	})
+
+	t.Run("exits before ready", func(t *testing.T) {
+		_, err := Start(testapp, Args("exit-code", "3"), ReadyOnLog("never matches"), Quiet())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exited before ready")
+	})
+
+	t.Run("ready timeout stops the process", func(t *testing.T) {
+		start := time.Now()
+		p, err := Start(testapp, Args("sleep", "1m"), ReadyOnLog("never matches"), ReadyTimeout(200*time.Millisecond))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "never matches")
+		_, _ = p.Wait()
+		require.True(t, time.Since(start) < 10*time.Second)
+	})
+
+	t.Run("stopped on exit", func(t *testing.T) {
+		start := time.Now()
+		p, err := Start(testapp, Args("sleep", "1m"))
+		require.NoError(t, err)
+		config.DoExit()
+		_, err = p.Wait()
+		require.Error(t, err)
+		require.True(t, time.Since(start) < 10*time.Second)
+	})
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, listener.Close()) }()
+	return listener.Addr().String()
+}
@@ -3,18 +3,22 @@ package run
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/anchore/go-make/color"
 	"github.com/anchore/go-make/config"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 	"github.com/anchore/go-make/stream"
 )
 
@@ -24,7 +28,62 @@ type Option func(context.Context, *exec.Cmd) error
 // Command runs a command, waits until completion, and returns stdout.
 // The first argument is the path to the binary and DOES NOT shell-split.
 // When not captured, stderr is output to os.Stderr and returned as part of the error text.
+// Secrets are redacted from output written to the stdout and stderr writers, but not from the returned stdout.
 func Command(cmd string, opts ...Option) (string, error) {
+	var stdin []byte
+	for attempt := 1; ; attempt++ {
+		c, err := newCommand(Context(), cmd, opts...)
+		if err != nil {
+			return "", err
+		}
+		// stdin is drained by the first attempt, so it is buffered to provide the same input to every attempt
+		if c.cfg.retry != nil && c.Stdin != nil {
+			if attempt == 1 {
+				stdin, err = io.ReadAll(c.Stdin)
+				if err != nil {
+					return c.result(fmt.Errorf("unable to read stdin: %w", err))
+				}
+			}
+			c.Stdin = bytes.NewReader(stdin)
+		}
+		out, err := c.result(c.Run())
+		if !c.shouldRetry(attempt) {
+			return out, err
+		}
+		delay := c.cfg.retry.backoff(attempt)
+		log.Info(color.Yellow("attempt %d of %d failed: '%v %v', retrying in %v"), attempt, c.cfg.retry.attempts,
+			displayPath(cmd), strings.Join(c.args(), " "), delay)
+		select {
+		case <-Context().Done():
+			return out, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// command is an exec.Cmd with all options applied, along with the state needed to report results
+type command struct {
+	*exec.Cmd
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	timer  *time.Timer
+	exited func()
+	name   string
+	opts   []Option
+	cfg    *runConfig
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	flush  []io.Closer
+
+	// retryStderr captures stderr for retry conditions regardless of where it is written
+	retryStderr bytes.Buffer
+	failed      bool
+}
+
+func newCommand(ctx context.Context, cmd string, opts ...Option) (*command, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	out := &command{ctx: ctx, cancel: cancel, name: cmd, cfg: &runConfig{}}
+
 	// by default, only capture output without duplicating it to logs
 	opts = append([]Option{func(_ context.Context, cmd *exec.Cmd) error {
 		cmd.Stdout = io.Discard
@@ -33,57 +92,87 @@ func Command(cmd string, opts ...Option) (string, error) {
 		return nil
 	}}, opts...)
 
-	stdout := bytes.Buffer{}
-	stderr := bytes.Buffer{}
-	opts = append(opts, func(_ context.Context, cmd *exec.Cmd) error {
+	opts = append(opts, func(ctx context.Context, cmd *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		prefixed := func(w io.Writer) io.Writer {
+			if cfg == nil || cfg.prefix == "" || w == io.Discard {
+				return w
+			}
+			p := stream.Prefix(w, cfg.prefix)
+			out.flush = append(out.flush, p)
+			return p
+		}
+		// output passed through to other writers may end up in logs, so secrets are redacted
+		output := func(w io.Writer) io.Writer {
+			if w == io.Discard {
+				return w
+			}
+			r := redact.Writer(prefixed(w))
+			out.flush = append(out.flush, r)
+			return r
+		}
 		// if we are not outputting Stdout, capture and return it
 		if cmd.Stdout == io.Discard {
-			cmd.Stdout = &stdout
+			cmd.Stdout = &out.stdout
+		} else {
+			cmd.Stdout = output(cmd.Stdout)
 		}
 		// if the user isn't capturing stderr, we print to stderr by default and don't need to duplicate this in errors
 		if cmd.Stderr != os.Stderr {
-			cmd.Stderr = stream.Tee(cmd.Stderr, &stderr)
+			cmd.Stderr = stream.Tee(output(cmd.Stderr), &out.stderr)
+		} else {
+			cmd.Stderr = output(cmd.Stderr)
+		}
+		if cfg != nil {
+			cmd.Env = composeEnv(cfg, cmd.Env)
+		}
+		if cfg != nil && cfg.retry != nil {
+			cmd.Stderr = stream.Tee(cmd.Stderr, &out.retryStderr)
+		}
+		if cfg != nil {
+			// stdout and stderr are written from separate goroutines, so line handlers are serialized
+			lock := &sync.Mutex{}
+			for _, fn := range cfg.onLine {
+				fn := func(stream, line string) {
+					lock.Lock()
+					defer lock.Unlock()
+					fn(stream, line)
+				}
+				stdoutLines := stream.Lines(func(line string) { fn("stdout", line) })
+				stderrLines := stream.Lines(func(line string) { fn("stderr", line) })
+				out.flush = append(out.flush, stdoutLines, stderrLines)
+				cmd.Stdout = stream.Tee(cmd.Stdout, stdoutLines)
+				cmd.Stderr = stream.Tee(cmd.Stderr, stderrLines)
+			}
 		}
 		return nil
 	})
+	out.opts = opts
 
 	// create the command, this will look it up based on path:
-	c := exec.CommandContext(Context(), cmd)
-
-	env := os.Environ()
-	var dropped []string
-	for i := 0; i < len(env); i++ {
-		nameValue := strings.SplitN(env[i], "=", 2)
-		if skipEnvVar(nameValue[0]) {
-			dropped = append(dropped, nameValue[0])
-			continue
-		}
-		log.Trace(color.Grey("adding environment entry: %v", env[i]))
-		c.Env = append(c.Env, env[i])
-	}
+	c := exec.CommandContext(ctx, cmd)
+	out.Cmd = c
 
-	for _, e := range dropped {
-		log.Trace(color.Grey("dropped environment entry: %v", e))
-	}
+	// the environment is composed after all options are applied, see composeEnv; a non-nil Env prevents
+	// exec from inheriting the entire environment
+	c.Env = []string{}
 
-	cfg := runConfig{}
-	ctx := context.WithValue(Context(), runConfig{}, &cfg)
+	ctx = context.WithValue(ctx, runConfigKey{}, out.cfg)
 
 	// finally, apply all the options to modify the command
 	for _, opt := range opts {
 		err := opt(ctx, c)
 		if err != nil {
-			return "", err
+			cancel(err)
+			return nil, err
 		}
 	}
 
-	args := shortenedArgs(c.Args[1:]) // exec.Command sets the cmd to Args[0]
-
 	logFunc := log.Info
-	if cfg.quiet {
+	if out.cfg.quiet {
 		logFunc = log.Debug
 	}
-	logFunc("$ %v %v", displayPath(cmd), strings.Join(args, " "))
+	logFunc("$ %v %v", displayPath(cmd), strings.Join(out.args(), " "))
 
 	// print out c.Env -- GOROOT vs GOBIN
 	log.Trace("ENV: %v", c.Env)
@@ -91,10 +180,44 @@ func Command(cmd string, opts ...Option) (string, error) {
 	// WaitDelay specifies the time to wait after context cancellation (and the Cancel func
 	// being called) before force-killing the process.
 	c.WaitDelay = 11 * time.Second
-	osExecOpts(c)
+	out.exited = osExecOpts(c)
 
-	// execute
-	err := c.Run()
+	return out, nil
+}
+
+// Start starts the command, and the Timeout from when the process is started
+func (c *command) Start() error {
+	if c.cfg.timeout > 0 {
+		c.timer = time.AfterFunc(c.cfg.timeout, func() {
+			c.cancel(ErrTimeout)
+		})
+	}
+	return c.Cmd.Start()
+}
+
+// Run starts the command and waits for it to complete
+func (c *command) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+func (c *command) args() []string {
+	return shortenedArgs(c.Args[1:]) // exec.Command sets the cmd to Args[0]
+}
+
+// result flushes any pending output and returns the captured stdout along with a descriptive error, if the command failed
+func (c *command) result(err error) (string, error) {
+	defer c.cancel(nil)
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.exited()
+	// close in reverse order, so writers are flushed before the writers they write to
+	for i := len(c.flush) - 1; i >= 0; i-- {
+		log.Error(c.flush[i].Close())
+	}
 
 	exitCode := 0
 	if c.ProcessState != nil {
@@ -102,24 +225,104 @@ func Command(cmd string, opts ...Option) (string, error) {
 	}
 	if err != nil {
 		fullStdOut := ""
-		if stdout.Len() > 0 {
-			fullStdOut = "\nSTDOUT:\n" + stdout.String()
+		if c.stdout.Len() > 0 {
+			fullStdOut = "\nSTDOUT:\n" + c.stdout.String()
 		}
-		if stderr.Len() > 0 {
-			fullStdOut += "\nSTDERR:\n" + stderr.String()
+		if c.stderr.Len() > 0 {
+			fullStdOut += "\nSTDERR:\n" + c.stderr.String()
 		}
-		err = lang.NewStackTraceError(fmt.Errorf("error executing: '%s %s': %w", cmd, printArgs(opts), err)).
+		err = lang.NewStackTraceError(fmt.Errorf("error executing: '%s %s': %w", c.name, printArgs(c.opts), c.reason(err))).
 			WithExitCode(exitCode).
-			WithLog(fullStdOut)
+			WithLog(redact.Apply(fullStdOut))
 	}
-	if err != nil || exitCode > 0 {
-		if cfg.noFail {
-			log.Debug("error executing: '%v %v' exit code: %v: %v", displayPath(cmd), strings.Join(args, " "), exitCode, err)
+	c.failed = err != nil || exitCode > 0
+	if c.failed {
+		if c.cfg.noFail {
+			log.Debug("error executing: '%v %v' exit code: %v: %v", displayPath(c.name), strings.Join(c.args(), " "), exitCode, err)
 			err = nil
 		}
 	}
 
-	return strings.TrimSpace(stdout.String()), err
+	return strings.TrimSpace(c.stdout.String()), err
+}
+
+// shouldRetry indicates the command failed and should be run again according to the Retry settings
+func (c *command) shouldRetry(attempt int) bool {
+	r := c.cfg.retry
+	if !c.failed || r == nil || attempt >= r.attempts || Context().Err() != nil {
+		return false
+	}
+	exitCode := -1
+	if c.ProcessState != nil {
+		exitCode = c.ProcessState.ExitCode()
+	}
+	return r.retryIf == nil || r.retryIf(exitCode, c.retryStderr.String())
+}
+
+// reason wraps the error from running the command to distinguish a timeout, cancellation, or termination by signal
+// from a non-zero exit code
+func (c *command) reason(err error) error {
+	if errors.Is(context.Cause(c.ctx), ErrTimeout) {
+		return fmt.Errorf("%w after %v: %w", ErrTimeout, c.cfg.timeout, err)
+	}
+	if c.ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+	if c.ProcessState != nil {
+		if status, ok := c.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return &SignalError{Signal: status.Signal(), Err: err}
+		}
+	}
+	return err
+}
+
+// Timeout stops the command if it has not completed within the duration, first with an interrupt signal to the
+// process group, and forcibly after the WaitDelay; the resulting error matches ErrTimeout
+func Timeout(timeout time.Duration) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.timeout = timeout
+		}
+		return nil
+	}
+}
+
+// Retry runs the command again when it fails, up to the total number of attempts, waiting for the duration returned
+// by backoff given the number of the failed attempt, e.g. ExponentialBackoff(time.Second). If retryIf is provided, the
+// command is only retried when it returns true given the exit code and stderr output of the failed attempt. Any Stdin
+// is read entirely before the first attempt, so each attempt receives the same input.
+func Retry(attempts int, backoff func(attempt int) time.Duration, retryIf func(exitCode int, stderr string) bool) Option {
+	if backoff == nil {
+		backoff = func(int) time.Duration { return time.Second }
+	}
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.retry = &retry{attempts: attempts, backoff: backoff, retryIf: retryIf}
+		}
+		return nil
+	}
+}
+
+// ExponentialBackoff is a Retry backoff, which waits for the interval after the first attempt, doubling for each
+// subsequent attempt
+func ExponentialBackoff(interval time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return interval << max(attempt-1, 0)
+	}
+}
+
+// StderrContains is a Retry condition, which matches stderr output containing any of the provided values
+func StderrContains(values ...string) func(exitCode int, stderr string) bool {
+	return func(_ int, stderr string) bool {
+		for _, v := range values {
+			if strings.Contains(stderr, v) {
+				return true
+			}
+		}
+		return false
+	}
 }
 
 // Args appends args to the command
@@ -168,7 +371,7 @@ func Quiet() Option {
 			if cmd.Stderr == os.Stderr {
 				cmd.Stderr = io.Discard
 			}
-			cfg, _ := ctx.Value(runConfig{}).(*runConfig)
+			cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
 			if cfg != nil {
 				cfg.quiet = true
 			}
@@ -177,10 +380,36 @@ func Quiet() Option {
 	}
 }
 
+// OnLine calls fn with each line of output as it is written, along with the stream it was written to: "stdout" or
+// "stderr"; output is still written to the configured writers. Calls are serialized across both streams, so fn does
+// not need to synchronize access to state shared only with other line handlers of the command, but lines of the two
+// streams may be received in a different order than the command wrote them
+func OnLine(fn func(stream, line string)) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.onLine = append(cfg.onLine, fn)
+		}
+		return nil
+	}
+}
+
+// PrefixOutput prepends the current log.Prefix, e.g. the running task name, to each line of output written to stdout
+// and stderr; captured output is not prefixed
+func PrefixOutput() Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
+		if cfg != nil {
+			cfg.prefix = log.Prefix
+		}
+		return nil
+	}
+}
+
 // NoFail logs at Debug level instead of panicking
 func NoFail() Option {
 	return func(ctx context.Context, cmd *exec.Cmd) error {
-		cfg, _ := ctx.Value(runConfig{}).(*runConfig)
+		cfg, _ := ctx.Value(runConfigKey{}).(*runConfig)
 		if cfg != nil {
 			cfg.noFail = true
 		}
@@ -212,7 +441,7 @@ func Stdin(in io.Reader) Option {
 	}
 }
 
-// Env adds an environment variable to the command
+// Env sets an environment variable for the command, replacing any inherited or previously set value
 func Env(key, val string) Option {
 	return func(_ context.Context, cmd *exec.Cmd) error {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, val))
@@ -252,16 +481,6 @@ func shortenedArgs(args []string) []string {
 	return out
 }
 
-func skipEnvVar(s string) bool {
-	// it causes problems to keep go environment variables in embedded go executions,
-	// removing them all seems to fix this up; a user needing a specific GO installation
-	// can specify environment variables to run commands using the Env
-	if strings.HasPrefix(s, "GO") || strings.HasPrefix(s, "CGO_") {
-		return true
-	}
-	return false
-}
-
 func displayPath(cmd string) string {
 	if config.Debug {
 		return auxParent(cmd)
@@ -309,10 +528,30 @@ func printArgs(args []Option) string {
 			}
 		}
 	}
-	return strings.Join(c.Args, " ")
+	return redact.Apply(strings.Join(c.Args, " "))
 }
 
+// runConfigKey is the context key for the *runConfig of the command being built
+type runConfigKey struct{}
+
 type runConfig struct {
-	quiet  bool
-	noFail bool
+	quiet   bool
+	noFail  bool
+	prefix  string
+	onLine  []func(stream, line string)
+	timeout time.Duration
+	retry   *retry
+
+	cleanEnv bool
+	inherit  []string
+	drop     []string
+
+	ready        []func(ctx context.Context) error
+	readyTimeout time.Duration
+}
+
+type retry struct {
+	attempts int
+	backoff  func(attempt int) time.Duration
+	retryIf  func(exitCode int, stderr string) bool
 }
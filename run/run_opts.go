@@ -4,10 +4,14 @@ package run
 
 import (
 	"os/exec"
+	"sync"
 	"syscall"
+	"time"
 )
 
-func osExecOpts(c *exec.Cmd) {
+// osExecOpts configures the command to stop the entire process group on cancellation, returning a func to call once
+// the command has exited, which stops any pending forced kill of the process group
+func osExecOpts(c *exec.Cmd) (exited func()) {
 	// set pgid so any kill operations apply to spawned children
 	c.SysProcAttr = &syscall.SysProcAttr{
 		Pgid:    0,
@@ -15,10 +19,30 @@ func osExecOpts(c *exec.Cmd) {
 	}
 	// when the context is cancelled, send SIGINT to the entire process group for
 	// graceful shutdown instead of the default SIGKILL to just the child process.
+	// exec only kills the child process once the WaitDelay elapses, so the rest of
+	// the group is killed at the same time to avoid leaving orphaned grandchildren.
+	lock := sync.Mutex{}
+	var kill *time.Timer
 	c.Cancel = func() error {
 		if c.Process == nil {
 			return nil
 		}
-		return syscall.Kill(-c.Process.Pid, syscall.SIGINT)
+		pgid := c.Process.Pid
+		if c.WaitDelay > 0 {
+			lock.Lock()
+			kill = time.AfterFunc(c.WaitDelay, func() {
+				_ = syscall.Kill(-pgid, syscall.SIGKILL)
+			})
+			lock.Unlock()
+		}
+		return syscall.Kill(-pgid, syscall.SIGINT)
+	}
+	return func() {
+		// once the process has exited, the pgid may be reused by an unrelated process group
+		lock.Lock()
+		defer lock.Unlock()
+		if kill != nil {
+			kill.Stop()
+		}
 	}
 }
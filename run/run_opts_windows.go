@@ -5,7 +5,8 @@ import (
 	"os/exec"
 )
 
-func osExecOpts(c *exec.Cmd) {
+// osExecOpts configures the command to be killed on cancellation, returning a func to call once the command has exited
+func osExecOpts(c *exec.Cmd) (exited func()) {
 	// on Windows, os.Process.Signal(os.Interrupt) is not supported for child processes.
 	// Instead, kill the process directly when the context is cancelled. This is less
 	// graceful than the Unix approach but is the only reliable option on Windows.
@@ -15,4 +16,5 @@ func osExecOpts(c *exec.Cmd) {
 		}
 		return c.Process.Signal(os.Kill)
 	}
+	return func() {}
 }
@@ -2,28 +2,29 @@ package run
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/anchore/go-make/config"
 	. "github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/stream"
 )
 
 func Test_Command(t *testing.T) {
 	buf1 := bytes.Buffer{}
 	buf2 := bytes.Buffer{}
+	var lines []string
 
-	tmpDir := t.TempDir()
-	testapp := filepath.Join(tmpDir, "testapp")
-	if config.Windows {
-		testapp += ".exe"
-	}
-	_, err := Command("go", Args("build", "-C", filepath.Join("testdata", "testapp"), "-o", testapp, "."))
-	require.NoError(t, err)
+	testapp := buildTestApp(t)
 
 	tests := []struct {
 		name     string
@@ -64,6 +65,33 @@ func Test_Command(t *testing.T) {
 				require.Contains(t, err.Error(), "some-stderr-value")
 			},
 		},
+		{
+			name: "on line receives stdout and stderr lines",
+			args: List(Args("stdout", "line-1\nline-2\n", "stderr", "err-line"), Stderr(&buf2), OnLine(func(stream, line string) {
+				lines = append(lines, stream+": "+line)
+			})),
+			validate: func(t *testing.T, commandLog, result string) {
+				require.Equal(t, "line-1\nline-2", result)
+				require.Equal(t, "err-line", buf2.String())
+				require.EqualElements(t, []string{"stdout: line-1", "stdout: line-2", "stderr: err-line"}, lines)
+			},
+		},
+		{
+			name: "prefix output",
+			args: List(Args("stdout", "line-1\nline-2", "stderr", "err-line\n"), Stdout(&buf1), Stderr(&buf2), PrefixOutput()),
+			validate: func(t *testing.T, commandLog, result string) {
+				require.Equal(t, "", result)
+				require.Equal(t, "[prefix] line-1\n[prefix] line-2\n", buf1.String())
+				require.Equal(t, "[prefix] err-line\n", buf2.String())
+			},
+		},
+		{
+			name: "prefix output does not alter captured stdout",
+			args: List(Args("stdout", "some-value"), PrefixOutput()),
+			validate: func(t *testing.T, commandLog, result string) {
+				require.Equal(t, "some-value", result)
+			},
+		},
 		{
 			name: "stdin",
 			args: List(Args("stdin"), Quiet(), Stdin(strings.NewReader("some-stdin-value"))),
@@ -73,6 +101,8 @@ func Test_Command(t *testing.T) {
 		},
 	}
 
+	require.SetAndRestore(t, &log.Prefix, "[prefix] ")
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			commandLog := ""
@@ -87,3 +117,191 @@ func Test_Command(t *testing.T) {
 		})
 	}
 }
+
+func buildTestApp(t *testing.T) string {
+	t.Helper()
+	testapp := filepath.Join(t.TempDir(), "testapp")
+	if config.Windows {
+		testapp += ".exe"
+	}
+	_, err := Command("go", Args("build", "-C", filepath.Join("testdata", "testapp"), "-o", testapp, "."))
+	require.NoError(t, err)
+	return testapp
+}
+
+func Test_CommandErrors(t *testing.T) {
+	testapp := buildTestApp(t)
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := Command(testapp, Args("sleep", "1m"), Timeout(200*time.Millisecond))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrTimeout))
+		require.True(t, !errors.Is(err, ErrCanceled))
+		require.True(t, time.Since(start) < 10*time.Second)
+	})
+
+	t.Run("on line is serialized across streams", func(t *testing.T) {
+		count := 0
+		lines := strings.Repeat("some-line\n", 1000)
+		_, err := Command(testapp, Args("stdout", lines, "stderr", lines), Quiet(), OnLine(func(_, _ string) {
+			count++
+		}))
+		require.NoError(t, err)
+		require.Equal(t, 2000, count)
+	})
+
+	t.Run("completes within timeout", func(t *testing.T) {
+		out, err := Command(testapp, Args("stdout", "some-value"), Timeout(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, "some-value", out)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer SetContext(context.Background())
+		SetContext(ctx)
+		time.AfterFunc(200*time.Millisecond, cancel)
+		_, err := Command(testapp, Args("sleep", "1m"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrCanceled))
+		require.True(t, !errors.Is(err, ErrTimeout))
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		if config.Windows {
+			t.Skip("signals are not reported on windows")
+		}
+		_, err := Command(testapp, Args("kill-self", ""))
+		var sigErr *SignalError
+		require.True(t, errors.As(err, &sigErr))
+		require.Equal(t, os.Kill, sigErr.Signal)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		_, err := Command(testapp, Args("exit-code", "3"), Quiet())
+		var stackErr *StackTraceError
+		require.True(t, errors.As(err, &stackErr))
+		require.Equal(t, 3, stackErr.ExitCode)
+		var sigErr *SignalError
+		require.True(t, !errors.As(err, &sigErr))
+		require.True(t, !errors.Is(err, ErrTimeout) && !errors.Is(err, ErrCanceled))
+	})
+}
+
+func Test_Retry(t *testing.T) {
+	testapp := buildTestApp(t)
+	noDelay := func(int) time.Duration { return 0 }
+
+	tests := []struct {
+		name     string
+		failures int
+		retry    Option
+		attempts int
+		wantErr  require.ValidationError
+	}{
+		{
+			name:     "succeeds after retries",
+			failures: 2,
+			retry:    Retry(3, noDelay, nil),
+			attempts: 3,
+		},
+		{
+			name:     "fails after all attempts",
+			failures: 5,
+			retry:    Retry(3, noDelay, nil),
+			attempts: 3,
+			wantErr:  require.Error,
+		},
+		{
+			name:     "retry condition matches stderr",
+			failures: 1,
+			retry:    Retry(3, noDelay, StderrContains("temporary")),
+			attempts: 2,
+		},
+		{
+			name:     "retry condition does not match",
+			failures: 1,
+			retry:    Retry(3, noDelay, StderrContains("permanent")),
+			attempts: 1,
+			wantErr:  require.Error,
+		},
+		{
+			name:     "retry condition on exit code",
+			failures: 1,
+			retry:    Retry(3, noDelay, func(exitCode int, _ string) bool { return exitCode == 2 }),
+			attempts: 1,
+			wantErr:  require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			countFile := filepath.Join(t.TempDir(), "count")
+			_, err := Command(testapp, Args("count-file", countFile, "fail-times", fmt.Sprintf("%d", tt.failures)), tt.retry, Quiet())
+			tt.wantErr.Validate(t, err)
+			require.Equal(t, fmt.Sprintf("%d", tt.attempts), string(Return(os.ReadFile(countFile))))
+		})
+	}
+}
+
+func Test_RetryStdin(t *testing.T) {
+	testapp := buildTestApp(t)
+	countFile := filepath.Join(t.TempDir(), "count")
+
+	stderr := bytes.Buffer{}
+	_, err := Command(testapp, Args("stdin", "", "count-file", countFile, "fail-times", "1"),
+		Stdin(strings.NewReader("some-input;")), Stderr(&stderr), Retry(2, func(int) time.Duration { return 0 }, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(stderr.String(), "some-input;"))
+}
+
+func Test_ExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second)
+	require.Equal(t, time.Second, backoff(1))
+	require.Equal(t, 4*time.Second, backoff(3))
+
+	// the backoff is determined by the attempt, so reusing it does not continue from a previous command
+	require.Equal(t, time.Second, backoff(1))
+}
+
+func Test_CommandRedactsSecrets(t *testing.T) {
+	testapp := buildTestApp(t)
+	redact.Add("some-secret-value")
+
+	_, err := Command(testapp, Args("stdout", "output some-secret-value", "exit-code", "1"), Args("ignored", "some-secret-value"))
+	require.Error(t, err)
+	require.True(t, !strings.Contains(err.Error(), "some-secret-value"))
+	require.Contains(t, err.Error(), redact.Replacement)
+
+	var stackErr *StackTraceError
+	require.True(t, errors.As(err, &stackErr))
+	require.True(t, !strings.Contains(stackErr.Log, "some-secret-value"))
+}
+
+func Test_CommandRedactsPassthroughOutput(t *testing.T) {
+	testapp := buildTestApp(t)
+	redact.Add("passthrough-secret-value")
+
+	stdout := Return(os.Create(filepath.Join(t.TempDir(), "stdout")))
+	stderr := Return(os.Create(filepath.Join(t.TempDir(), "stderr")))
+	require.SetAndRestore(t, &os.Stdout, stdout)
+	require.SetAndRestore(t, &os.Stderr, stderr)
+
+	_, err := Command(testapp, Args("stdout", "out passthrough-secret-value", "stderr", "err passthrough-secret-value"),
+		Stdout(os.Stdout))
+	require.NoError(t, err)
+
+	out := string(Return(os.ReadFile(stdout.Name())))
+	require.Contains(t, out, "out "+redact.Replacement)
+	require.True(t, !strings.Contains(out, "passthrough-secret-value"))
+
+	errOut := string(Return(os.ReadFile(stderr.Name())))
+	require.Contains(t, errOut, "err "+redact.Replacement)
+	require.True(t, !strings.Contains(errOut, "passthrough-secret-value"))
+
+	// writers other than the process' stdout and stderr are redacted as well
+	buf := bytes.Buffer{}
+	_, err = Command(testapp, Args("stdout", "out passthrough-secret-value"), Stdout(stream.Tee(&buf)))
+	require.NoError(t, err)
+	require.Equal(t, "out "+redact.Replacement, buf.String())
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"strconv"
+	"time"
 )
 
 func g[T any](v T, err error) T {
@@ -15,6 +18,7 @@ func g[T any](v T, err error) T {
 
 func main() {
 	exit := 0
+	countFile := ""
 	for i := 1; i < len(os.Args); i++ {
 		switch os.Args[i] {
 		case "stdout":
@@ -28,6 +32,39 @@ func main() {
 				value += string(buf[0])
 			}
 			g(os.Stderr.WriteString(value))
+		case "sleep":
+			time.Sleep(g(time.ParseDuration(os.Args[i+1])))
+		case "serve":
+			listener := g(net.Listen("tcp", os.Args[i+1]))
+			g(os.Stdout.WriteString("listening on " + listener.Addr().String() + "\n"))
+			_ = http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+		case "kill-self":
+			_ = g(os.FindProcess(os.Getpid())).Signal(os.Kill)
+			time.Sleep(time.Minute)
+		case "env":
+			value, ok := os.LookupEnv(os.Args[i+1])
+			if !ok {
+				value = "<unset>"
+			}
+			g(os.Stdout.WriteString(value + "\n"))
+		case "count-file":
+			countFile = os.Args[i+1]
+		case "fail-times":
+			// fails the first N times it is run, tracking attempts in the count-file
+			count := 0
+			if contents, err := os.ReadFile(countFile); err == nil {
+				count = g(strconv.Atoi(string(contents)))
+			}
+			count++
+			if err := os.WriteFile(countFile, []byte(strconv.Itoa(count)), 0o600); err != nil {
+				panic(err)
+			}
+			if count <= g(strconv.Atoi(os.Args[i+1])) {
+				g(os.Stderr.WriteString("temporary failure " + strconv.Itoa(count)))
+				exit = 1
+			}
 		case "exit-code":
 			exit = g(strconv.Atoi(os.Args[i+1]))
 		}
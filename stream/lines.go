@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Lines creates a writer which calls fn with each complete line written, without the line ending; Close must be
+// called to receive a final line not terminated by a newline
+func Lines(fn func(line string)) io.WriteCloser {
+	return &lineWriter{fn: fn}
+}
+
+// Prefix creates a writer which writes each complete line to w with the prefix prepended; Close must be called to
+// write a final line not terminated by a newline
+func Prefix(w io.Writer, prefix string) io.WriteCloser {
+	return Lines(func(line string) {
+		_, _ = io.WriteString(w, prefix+line+"\n")
+	})
+}
+
+type lineWriter struct {
+	lock sync.Mutex
+	buf  []byte
+	fn   func(line string)
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.buf = append(l.buf, p...)
+	for {
+		idx := bytes.IndexByte(l.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		l.fn(strings.TrimSuffix(string(l.buf[:idx]), "\r"))
+		l.buf = l.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+func (l *lineWriter) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.buf) > 0 {
+		l.fn(strings.TrimSuffix(string(l.buf), "\r"))
+		l.buf = nil
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package stream
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Lines(t *testing.T) {
+	var lines []string
+	w := Lines(func(line string) {
+		lines = append(lines, line)
+	})
+
+	for _, s := range []string{"first", " line\nsecond line\r\n", "\n", "partial"} {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"first line", "second line", ""}, lines)
+
+	require.NoError(t, w.Close())
+	require.Equal(t, []string{"first line", "second line", "", "partial"}, lines)
+}
+
+func Test_Prefix(t *testing.T) {
+	buf := bytes.Buffer{}
+	w := Prefix(&buf, "[task] ")
+
+	_, err := w.Write([]byte("one\ntw"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("o\nthree"))
+	require.NoError(t, err)
+	require.Equal(t, "[task] one\n[task] two\n", buf.String())
+
+	require.NoError(t, w.Close())
+	require.Equal(t, "[task] one\n[task] two\n[task] three\n", buf.String())
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/anchore/go-make/binny"
+	"github.com/anchore/go-make/changes"
 	"github.com/anchore/go-make/color"
 	"github.com/anchore/go-make/config"
 	"github.com/anchore/go-make/file"
@@ -30,6 +31,11 @@ type Task struct {
 	// RunsOn when a task in RunsOn is executed, it will cause this task to be executed as a dependency
 	RunsOn []string
 
+	// Paths are optional globs relative to the git root, e.g. "**/*.go"; when config.ChangedOnly is enabled, the task
+	// and its dependencies are skipped if no changed file matches, see changes.Match. Tasks attached with RunsOn are
+	// still run, subject to their own Paths. Of the built-in tasks, only the gotest tasks declare Paths by default
+	Paths []string
+
 	// Tasks allows a hierarchy of tasks to be registered together; subtasks will be prefixed with the "<parent name>:",
 	// but their name will be added to the phase matching the name itself, so `binny` -> `clean` results in
 	// `binny:clean` and `clean` execution for the subtask.
@@ -185,9 +191,14 @@ func (t *taskRunner) runTask(name string) {
 			continue
 		}
 		t.run.Add(tsk)
+		// tasks attached with RunsOn declare their own Paths, so run even if this task is skipped
 		for _, dep := range t.findByLabel(tsk.Name) {
 			t.runTask(dep.Name)
 		}
+		if config.ChangedOnly && len(tsk.Paths) > 0 && !changes.Match(tsk.Paths...) {
+			log.Info(color.Grey("skipping %s, no changes matching: %v"), tsk.Name, strings.Join(tsk.Paths, ", "))
+			continue
+		}
 		for _, dep := range tsk.Dependencies {
 			t.runTask(dep)
 		}
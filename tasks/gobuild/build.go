@@ -0,0 +1,306 @@
+package gobuild
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/version"
+)
+
+// ChecksumsFile is the name of the file written to the DistDir with the sha256 checksums of all built binaries
+const ChecksumsFile = "checksums.txt"
+
+// DefaultPlatforms are the GOOS/GOARCH combinations built when no Platforms are specified
+var DefaultPlatforms = []string{
+	"linux/amd64",
+	"linux/arm64",
+	"darwin/amd64",
+	"darwin/arm64",
+	"windows/amd64",
+}
+
+func Tasks(options ...Option) Task {
+	cfg := defaultConfig()
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return Task{
+		Name:        cfg.Name,
+		Description: "build binaries for all platforms",
+		Run: func() {
+			Build(cfg)
+		},
+		Tasks: []Task{
+			{
+				Name:        cfg.Name + ":single-target",
+				Description: "build binaries for the current platform",
+				Run: func() {
+					single := cfg
+					single.Platforms = []string{config.OS + "/" + config.Arch}
+					Build(single)
+				},
+			},
+			{
+				Name:   cfg.Name + ":clean",
+				RunsOn: lang.List("clean"),
+				Run: func() {
+					file.Delete(cfg.DistDir)
+				},
+			},
+		},
+	}
+}
+
+type Config struct {
+	// Name is the task name
+	Name string
+	// Binaries are the main packages to build, defaults to the module root named after the module
+	Binaries []Binary
+	// Platforms are GOOS/GOARCH pairs to build, e.g. linux/amd64
+	Platforms []string
+	// DistDir is the output directory for binaries and the checksums file
+	DistDir string
+	// Parallel is the maximum number of concurrent builds
+	Parallel int
+	// LDFlags are additional linker flags
+	LDFlags []string
+	// Tags are build tags
+	Tags []string
+	// CGO enables cgo, which is disabled by default for portable binaries
+	CGO bool
+	// VersionVar, CommitVar and DateVar are the fully qualified variables set using -X linker flags
+	VersionVar string
+	CommitVar  string
+	DateVar    string
+}
+
+// Binary is a main package to build
+type Binary struct {
+	// Name is the binary name, used for the output file names
+	Name string
+	// Package is the main package, e.g. ./cmd/syft
+	Package string
+}
+
+func defaultConfig() Config {
+	return Config{
+		Name:       "build",
+		Platforms:  DefaultPlatforms,
+		DistDir:    "dist",
+		Parallel:   runtime.NumCPU(),
+		LDFlags:    []string{"-s", "-w"},
+		VersionVar: "main.version",
+		CommitVar:  "main.gitCommit",
+		DateVar:    "main.buildDate",
+	}
+}
+
+type Option func(*Config)
+
+func Name(name string) Option {
+	return func(c *Config) {
+		c.Name = name
+	}
+}
+
+// Binaries adds a main package to build, with the output named name, e.g. Binaries("syft", "./cmd/syft")
+func Binaries(name, pkg string) Option {
+	return func(c *Config) {
+		c.Binaries = append(c.Binaries, Binary{Name: name, Package: pkg})
+	}
+}
+
+// Platforms sets the GOOS/GOARCH pairs to build, e.g. Platforms("linux/amd64", "darwin/arm64")
+func Platforms(platforms ...string) Option {
+	return func(c *Config) {
+		c.Platforms = platforms
+	}
+}
+
+func DistDir(dir string) Option {
+	return func(c *Config) {
+		c.DistDir = dir
+	}
+}
+
+// Parallel sets the maximum number of concurrent builds, 1 builds sequentially
+func Parallel(n int) Option {
+	return func(c *Config) {
+		c.Parallel = max(n, 1)
+	}
+}
+
+func LDFlags(flags ...string) Option {
+	return func(c *Config) {
+		c.LDFlags = append(c.LDFlags, flags...)
+	}
+}
+
+func Tags(tags ...string) Option {
+	return func(c *Config) {
+		c.Tags = append(c.Tags, tags...)
+	}
+}
+
+func CGO() Option {
+	return func(c *Config) {
+		c.CGO = true
+	}
+}
+
+// VersionVars sets the fully qualified variables to inject the version, commit and build date into,
+// e.g. "github.com/anchore/syft/internal/version.version"; empty values are not set
+func VersionVars(version, commit, date string) Option {
+	return func(c *Config) {
+		c.VersionVar = version
+		c.CommitVar = commit
+		c.DateVar = date
+	}
+}
+
+// Build builds all binaries for all platforms to the DistDir and writes a checksums file
+func Build(cfg Config) {
+	start := time.Now()
+	binaries := cfg.Binaries
+	if len(binaries) == 0 {
+		binaries = []Binary{defaultBinary()}
+	}
+
+	type target struct {
+		binary Binary
+		goos   string
+		goarch string
+	}
+	var targets []target
+	for _, p := range cfg.Platforms {
+		goos, goarch, ok := strings.Cut(p, "/")
+		if !ok || goos == "" || goarch == "" {
+			panic(fmt.Errorf("invalid platform, expected <os>/<arch>: %s", p))
+		}
+		for _, b := range binaries {
+			targets = append(targets, target{binary: b, goos: goos, goarch: goarch})
+		}
+	}
+
+	file.EnsureDir(cfg.DistDir)
+	ldflags := append(versionFlags(cfg), cfg.LDFlags...)
+
+	var lock sync.Mutex
+	var errs []error
+	var outputs []string
+	sem := make(chan struct{}, max(cfg.Parallel, 1))
+	wg := sync.WaitGroup{}
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var out string
+			err := lang.Catch(func() {
+				out = buildTarget(cfg, t.binary, t.goos, t.goarch, ldflags)
+			})
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			outputs = append(outputs, out)
+		}()
+	}
+	wg.Wait()
+	lang.Throw(errors.Join(errs...))
+
+	writeChecksums(cfg.DistDir, outputs)
+	Log("Built %d binaries to %s in %v", len(outputs), cfg.DistDir, time.Since(start).Round(time.Millisecond))
+}
+
+func buildTarget(cfg Config, b Binary, goos, goarch string, ldflags []string) string {
+	out := filepath.Join(cfg.DistDir, OutputName(b.Name, goos, goarch))
+
+	args := []string{"build", "-trimpath", "-o", out}
+	if len(cfg.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(cfg.Tags, ","))
+	}
+	cgo := "0"
+	if cfg.CGO {
+		cgo = "1"
+	}
+	Run("go", run.Args(args...), run.LDFlags(ldflags...), run.Args(b.Package),
+		run.Env("GOOS", goos),
+		run.Env("GOARCH", goarch),
+		run.Env("CGO_ENABLED", cgo),
+	)
+	return out
+}
+
+// OutputName returns the file name of a binary built for the platform: <name>_<os>_<arch>, with .exe for windows
+func OutputName(name, goos, goarch string) string {
+	out := fmt.Sprintf("%s_%s_%s", name, goos, goarch)
+	if goos == "windows" {
+		out += ".exe"
+	}
+	return out
+}
+
+func versionFlags(cfg Config) []string {
+	var flags []string
+	set := func(variable string, value func() string) {
+		if variable != "" {
+			flags = append(flags, fmt.Sprintf("-X %s=%s", variable, value()))
+		}
+	}
+	set(cfg.VersionVar, version.Current)
+	set(cfg.CommitVar, git.Revision)
+	set(cfg.DateVar, buildDate)
+	return flags
+}
+
+// buildDate returns the build time in RFC3339 format, using SOURCE_DATE_EPOCH when set for reproducible builds
+func buildDate() string {
+	t := time.Now()
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		var seconds int64
+		if _, err := fmt.Sscanf(epoch, "%d", &seconds); err == nil {
+			t = time.Unix(seconds, 0)
+		}
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func defaultBinary() Binary {
+	name := filepath.Base(file.Cwd())
+	if f := gomod.Read(); f != nil && f.Module != nil {
+		name = path.Base(f.Module.Mod.Path)
+	}
+	return Binary{Name: name, Package: "."}
+}
+
+func writeChecksums(distDir string, outputs []string) {
+	slices.Sort(outputs)
+	contents := strings.Builder{}
+	for _, out := range outputs {
+		contents.WriteString(fmt.Sprintf("%s  %s\n", file.Sha256Hash(out), filepath.Base(out)))
+	}
+	checksums := filepath.Join(distDir, ChecksumsFile)
+	file.Write(checksums, contents.String())
+	log.Debug("wrote checksums: %s", checksums)
+}
@@ -0,0 +1,84 @@
+package gobuild
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/run"
+)
+
+const testMain = `package main
+
+import "fmt"
+
+var version, gitCommit, buildDate string
+
+func main() {
+	fmt.Println(version, gitCommit, buildDate)
+}
+`
+
+func Test_Build(t *testing.T) {
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "add app", map[string]string{
+		"go.mod":              "module example.com/tools/app\n\ngo 1.21\n",
+		"main.go":             testMain,
+		"cmd/other/main.go":   testMain,
+		"internal/lib/lib.go": "package lib\n",
+	})
+	require.Git(t, dir, "tag", "v1.2.3")
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	file.InDir(dir, func() {
+		cfg := defaultConfig()
+		Platforms(config.OS + "/" + config.Arch)(&cfg)
+		Parallel(2)(&cfg)
+		Build(cfg)
+
+		bin := filepath.Join("dist", OutputName("app", config.OS, config.Arch))
+		require.True(t, file.IsRegular(bin))
+
+		out := lang.Return(run.Command(lang.Return(filepath.Abs(bin))))
+		require.Equal(t, "v1.2.3 "+lang.Return(run.Command("git", run.Args("rev-parse", "--short", "HEAD")))+" 2023-11-14T22:13:20Z", out)
+
+		checksums := strings.TrimSpace(file.Read(filepath.Join("dist", ChecksumsFile)))
+		require.Equal(t, file.Sha256Hash(bin)+"  "+filepath.Base(bin), checksums)
+
+		cfg.Binaries = nil
+		Binaries("one", ".")(&cfg)
+		Binaries("two", "./cmd/other")(&cfg)
+		VersionVars("main.version", "", "")(&cfg)
+		Build(cfg)
+
+		lines := strings.Split(strings.TrimSpace(file.Read(filepath.Join("dist", ChecksumsFile))), "\n")
+		require.Equal(t, 2, len(lines))
+		require.Contains(t, lines[0], OutputName("one", config.OS, config.Arch))
+		require.Contains(t, lines[1], OutputName("two", config.OS, config.Arch))
+
+		out = lang.Return(run.Command(lang.Return(filepath.Abs(filepath.Join("dist", OutputName("two", config.OS, config.Arch))))))
+		require.Equal(t, "v1.2.3", out)
+	})
+}
+
+func Test_BuildInvalidPlatform(t *testing.T) {
+	cfg := defaultConfig()
+	Platforms("linux")(&cfg)
+	Binaries("app", ".")(&cfg)
+	file.InDir(t.TempDir(), func() {
+		err := lang.Catch(func() {
+			Build(cfg)
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid platform")
+	})
+}
+
+func Test_OutputName(t *testing.T) {
+	require.Equal(t, "syft_linux_arm64", OutputName("syft", "linux", "arm64"))
+	require.Equal(t, "syft_windows_amd64.exe", OutputName("syft", "windows", "amd64"))
+}
@@ -3,15 +3,17 @@ package golint
 import (
 	"context"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/changes"
 	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
 	"github.com/anchore/go-make/gomod"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
@@ -29,40 +31,221 @@ func init() {
 	}
 }
 
+// OutputFormat is a golangci-lint report format written to a file, in addition to the text output
+type OutputFormat string
+
+const (
+	// SARIFFormat reports can be uploaded to GitHub code scanning
+	SARIFFormat      OutputFormat = "sarif"
+	CheckstyleFormat OutputFormat = "checkstyle"
+)
+
+// Output is a golangci-lint report written to a file
+type Output struct {
+	Format OutputFormat
+	Path   string
+}
+
+// Formatter formats the source files in the current directory
+type Formatter struct {
+	Name   string
+	Format func(cfg Config)
+	// Diff returns a unified diff of the changes Format would make without modifying any files, used by the
+	// format check task
+	Diff func(cfg Config) string
+}
+
+var (
+	Gofmt = Formatter{
+		Name: "gofmt",
+		Format: func(_ Config) {
+			Run(`gofmt -w -s .`)
+		},
+		Diff: func(_ Config) string {
+			return formatDiff(`gofmt -d -s .`)
+		},
+	}
+	Gosimports = Formatter{
+		Name: "gosimports",
+		Format: func(_ Config) {
+			Run(`gosimports -w`, run.Args(gosimportsArgs()...))
+		},
+		Diff: func(_ Config) string {
+			return formatDiff(`gosimports -d`, gosimportsArgs()...)
+		},
+	}
+	// GolangciLintFmt runs the formatters configured in the golangci-lint config
+	GolangciLintFmt = Formatter{
+		Name: "golangci-lint fmt",
+		Format: func(cfg Config) {
+			Run(`golangci-lint fmt`, run.Args(configArgs(cfg)...))
+		},
+		Diff: func(cfg Config) string {
+			return formatDiff(`golangci-lint fmt --diff`, configArgs(cfg)...)
+		},
+	}
+	GoModTidy = Formatter{
+		Name: "go mod tidy",
+		Format: func(_ Config) {
+			Run(`go mod tidy`)
+		},
+		Diff: func(_ Config) string {
+			if !hasModTidyDiff() {
+				log.Warn("go mod tidy -diff requires go 1.23 or later, skipping check")
+				return ""
+			}
+			return formatDiff(`go mod tidy -diff`)
+		},
+	}
+)
+
+// DefaultFormatters are run by the format task when no Formatters are specified
+var DefaultFormatters = []Formatter{Gofmt, Gosimports, GoModTidy}
+
+type Config struct {
+	// ConfigFile is the golangci-lint config file, defaults to the golangci-lint config discovery
+	ConfigFile string
+	// Tests indicates test files are linted
+	Tests bool
+	// NewFromRev only reports lint issues in changes since the merge base with changes.Base
+	NewFromRev bool
+	// Outputs are reports written in addition to the text output, e.g. SARIF for code scanning
+	Outputs []Output
+	// Formatters are run by the format task
+	Formatters []Formatter
+	// HygieneRules are repository checks run during static analysis
+	HygieneRules []HygieneRule
+	// LicensePolicy is the bouncer license policy, defaults to the project's .bouncer.yaml
+	LicensePolicy *LicensePolicy
+	// RunOptions are applied to all golangci-lint and bouncer commands
+	RunOptions []run.Option
+	// Paths skip static analysis when config.ChangedOnly is enabled and no changed file matches, see Task.Paths; not
+	// set by default, since hygiene rules check all files
+	Paths []string
+}
+
+func defaultConfig() Config {
+	return Config{
+		Tests:        true,
+		Formatters:   DefaultFormatters,
+		HygieneRules: []HygieneRule{MalformedFilenames},
+	}
+}
+
+// Option configures the golint tasks. Options which are run options, e.g. golint.Option(run.Env("GOGC", "50")), are
+// applied to all golangci-lint and bouncer commands, the same as RunOptions
 type Option run.Option
 
-func SkipTests() Option {
-	return func(ctx context.Context, cmd *exec.Cmd) error {
-		if strings.Contains(cmd.Args[0], "golangci-lint") {
-			cmd.Args = append(cmd.Args, "--tests=false")
+// configKey is the context key of the Config being built by newConfig
+type configKey struct{}
+
+// configOption returns an Option which modifies the Config; it has no effect when applied to a command
+func configOption(fn func(*Config)) Option {
+	return func(ctx context.Context, _ *exec.Cmd) error {
+		if c, ok := ctx.Value(configKey{}).(*Config); ok {
+			fn(c)
 		}
 		return nil
 	}
 }
 
+func SkipTests() Option {
+	return configOption(func(c *Config) {
+		c.Tests = false
+	})
+}
+
+// Paths sets the globs of files which affect static analysis, see Task.Paths
+func Paths(globs ...string) Option {
+	return configOption(func(c *Config) {
+		c.Paths = globs
+	})
+}
+
+// ConfigFile sets the golangci-lint config file
+func ConfigFile(path string) Option {
+	return configOption(func(c *Config) {
+		c.ConfigFile = path
+	})
+}
+
+// NewFromRev only reports lint issues in changes since the merge base with changes.Base, which may be set with
+// the CHANGES_BASE environment variable
+func NewFromRev() Option {
+	return configOption(func(c *Config) {
+		c.NewFromRev = true
+	})
+}
+
+// SARIF writes a SARIF report to the path, which can be uploaded to GitHub code scanning
+func SARIF(path string) Option {
+	return Outputs(Output{Format: SARIFFormat, Path: path})
+}
+
+// Checkstyle writes a checkstyle report to the path
+func Checkstyle(path string) Option {
+	return Outputs(Output{Format: CheckstyleFormat, Path: path})
+}
+
+func Outputs(outputs ...Output) Option {
+	return configOption(func(c *Config) {
+		c.Outputs = append(c.Outputs, outputs...)
+	})
+}
+
+// Formatters sets the formatters run by the format task, e.g. Formatters(golint.GolangciLintFmt, golint.GoModTidy)
+func Formatters(formatters ...Formatter) Option {
+	return configOption(func(c *Config) {
+		c.Formatters = formatters
+	})
+}
+
+// HygieneRules adds repository checks run during static analysis, in addition to MalformedFilenames
+func HygieneRules(rules ...HygieneRule) Option {
+	return configOption(func(c *Config) {
+		c.HygieneRules = append(c.HygieneRules, rules...)
+	})
+}
+
+// Licenses configures bouncer license checks with the policy, which is written to a temporary config file
+func Licenses(policy LicensePolicy) Option {
+	return configOption(func(c *Config) {
+		c.LicensePolicy = &policy
+	})
+}
+
+// RunOptions are applied to all golangci-lint and bouncer commands
+func RunOptions(options ...run.Option) Option {
+	return configOption(func(c *Config) {
+		c.RunOptions = append(c.RunOptions, options...)
+	})
+}
+
 func Tasks(options ...Option) Task {
 	return Task{
 		Tasks: []Task{
 			StaticAnalysisTask(options...),
-			FormatTask(),
+			FormatTask(options...),
 			LintFixTask(options...),
 		},
 	}
 }
 
 func StaticAnalysisTask(options ...Option) Task {
+	cfg := newConfig(options)
 	return Task{
 		Name:        "static-analysis",
 		Description: "run lint checks",
 		RunsOn:      lang.List("default"),
+		Paths:       cfg.Paths,
 		Run: func() {
 			if hasModTidyDiff() {
 				Run("go mod tidy -diff")
 			}
 			log.Debug("CWD: %s", file.Cwd())
-			Run("golangci-lint run", toRunOpts(options)...)
-			lang.Throw(findMalformedFilenames("."))
-			Run(`bouncer check ./...`, toRunOpts(options)...)
+			Lint(cfg)
+			CheckHygiene(cfg.HygieneRules...)
+			CheckLicenses(cfg)
 		},
 	}
 }
@@ -79,67 +262,146 @@ func hasModTidyDiff() bool {
 	return lang.Return(strconv.Atoi(parts[1])) >= 23
 }
 
-func FormatTask() Task {
+func FormatTask(options ...Option) Task {
+	cfg := newConfig(options)
 	return Task{
 		Name:        "format",
 		Description: "format all source files",
 		Run: func() {
-			Run(`gofmt -w -s .`)
-			if template.Globals["LocalPackage"] != nil {
-				Run(`gosimports -local {{LocalPackage}} -w .`)
-			} else {
-				Run(`gosimports -w .`)
+			for _, f := range cfg.Formatters {
+				log.Debug("formatting with %s", f.Name)
+				f.Format(cfg)
 			}
-			Run(`go mod tidy`)
 		},
+		Tasks: []Task{
+			{
+				Name:        "format:check",
+				Description: "fail if any source files are not formatted, without modifying them",
+				Run: func() {
+					FormatCheck(cfg)
+				},
+			},
+		},
+	}
+}
+
+// FormatCheck prints the changes the formatters would make and fails if there are any
+func FormatCheck(cfg Config) {
+	var unformatted []string
+	for _, f := range cfg.Formatters {
+		if f.Diff == nil {
+			log.Warn("formatter %s does not support checking, skipping", f.Name)
+			continue
+		}
+		diff := f.Diff(cfg)
+		if strings.TrimSpace(diff) == "" {
+			continue
+		}
+		unformatted = append(unformatted, f.Name)
+		fmt.Println(diff)
+	}
+	if len(unformatted) > 0 {
+		panic(fmt.Errorf("files are not formatted according to: %s; run the format task to fix", strings.Join(unformatted, ", ")))
+	}
+}
+
+// formatDiff runs a formatter in diff mode, which may exit non-zero when there are differences; the formatter
+// only fails when it reports errors without a diff, such as for unparsable files
+func formatDiff(cmd string, args ...string) string {
+	var stderr []string
+	out := Run(cmd, run.Args(args...), run.Quiet(), run.NoFail(), run.OnLine(func(stream, line string) {
+		if stream == "stderr" {
+			stderr = append(stderr, line)
+		}
+	}))
+	if out == "" && len(stderr) > 0 {
+		panic(fmt.Errorf("%s failed: %s", cmd, strings.Join(stderr, "\n")))
 	}
+	return out
+}
+
+func gosimportsArgs() []string {
+	if local := template.Render("{{LocalPackage}}"); local != "" {
+		return []string{"-local", local, "."}
+	}
+	return []string{"."}
 }
 
 func LintFixTask(options ...Option) Task {
+	cfg := newConfig(options)
 	return Task{
 		Name:         "lint-fix",
 		Description:  "format and run lint fix",
 		Dependencies: lang.List("format"),
 		Run: func() {
-			Run("golangci-lint run --fix", toRunOpts(options)...)
+			// reports are only written by static analysis
+			fixCfg := cfg
+			fixCfg.Outputs = nil
+			Run("golangci-lint run --fix", runOpts(fixCfg, lintArgs(fixCfg)...)...)
 		},
 	}
 }
 
-func toRunOpts(options []Option) []run.Option {
-	var out []run.Option
-	for _, opt := range options {
-		out = append(out, run.Option(opt))
+// Lint runs golangci-lint, writing any configured reports
+func Lint(cfg Config) {
+	for _, output := range cfg.Outputs {
+		file.EnsureDir(filepath.Dir(output.Path))
 	}
-	return out
+	Run("golangci-lint run", runOpts(cfg, lintArgs(cfg)...)...)
 }
 
-func findMalformedFilenames(root string) error {
-	var malformedFilenames []string
+func newConfig(options []Option) Config {
+	cfg := defaultConfig()
+	// options are applied to a placeholder command to configure the Config, and are kept as run options, which has no
+	// effect for config options; errors are reported when run options are applied to the commands
+	ctx := context.WithValue(context.Background(), configKey{}, &cfg)
+	var runOptions []run.Option
+	for _, opt := range options {
+		_ = opt(ctx, &exec.Cmd{})
+		runOptions = append(runOptions, run.Option(opt))
+	}
+	cfg.RunOptions = append(cfg.RunOptions, runOptions...)
+	return cfg
+}
 
-	err := filepath.Walk(root, func(path string, _ os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// runOpts returns the RunOptions with the additional command args
+func runOpts(cfg Config, args ...string) []run.Option {
+	return append(slices.Clone(cfg.RunOptions), run.Args(args...))
+}
 
-		// check if the filename contains the ':' character
-		if strings.Contains(path, ":") {
-			malformedFilenames = append(malformedFilenames, path)
-		}
+func configArgs(cfg Config) []string {
+	if cfg.ConfigFile == "" {
 		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("error walking through files: %w", err)
 	}
+	return []string{"--config", cfg.ConfigFile}
+}
 
-	if len(malformedFilenames) > 0 {
-		fmt.Println("\nfound unsupported filename characters:")
-		for _, filename := range malformedFilenames {
-			fmt.Println(filename)
-		}
-		return fmt.Errorf("\nerror: unsupported filename characters found")
+func lintArgs(cfg Config) []string {
+	args := configArgs(cfg)
+	if !cfg.Tests {
+		args = append(args, "--tests=false")
 	}
+	if cfg.NewFromRev {
+		args = append(args, "--new-from-rev", newFromRev())
+	}
+	if len(cfg.Outputs) > 0 {
+		// the text output is only written by default when no other outputs are configured
+		args = append(args, "--output.text.path=stdout")
+	}
+	for _, output := range cfg.Outputs {
+		args = append(args, fmt.Sprintf("--output.%s.path=%s", output.Format, output.Path))
+	}
+	return args
+}
 
-	return nil
+// newFromRev returns the merge base with changes.Base, or the base itself when the merge base is not available,
+// such as in shallow clones
+func newFromRev() string {
+	base := changes.Base()
+	rev, err := git.MergeBase(base, "HEAD")
+	if err != nil {
+		log.Debug("unable to find merge base with %v, using it directly: %v", base, err)
+		return base
+	}
+	return rev
 }
@@ -0,0 +1,116 @@
+package golint
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/run"
+)
+
+func Test_StaticAnalysisTaskPaths(t *testing.T) {
+	require.Equal(t, 0, len(StaticAnalysisTask().Paths))
+	require.Equal(t, []string{"**/*.go", ".golangci.yaml"}, StaticAnalysisTask(Paths("**/*.go", ".golangci.yaml")).Paths)
+}
+
+func Test_lintArgs(t *testing.T) {
+	require.Equal(t, 0, len(lintArgs(defaultConfig())))
+
+	dir := require.GitRepo(t)
+	base := require.Git(t, dir, "rev-parse", "HEAD")
+	require.Git(t, dir, "checkout", "-b", "feature")
+	require.GitCommit(t, dir, "some change", map[string]string{"main.go": "package main"})
+	t.Setenv("CHANGES_BASE", "main")
+
+	file.InDir(dir, func() {
+		cfg := newConfig([]Option{
+			SkipTests(),
+			ConfigFile(".golangci.ci.yaml"),
+			NewFromRev(),
+			SARIF("reports/lint.sarif"),
+			Checkstyle("reports/lint.xml"),
+		})
+		require.Equal(t, []string{
+			"--config", ".golangci.ci.yaml",
+			"--tests=false",
+			"--new-from-rev", base,
+			"--output.text.path=stdout",
+			"--output.sarif.path=reports/lint.sarif",
+			"--output.checkstyle.path=reports/lint.xml",
+		}, lintArgs(cfg))
+	})
+}
+
+func Test_runOptionCompatibility(t *testing.T) {
+	// run options converted to golint options are applied to commands, as before Config was introduced
+	cfg := newConfig([]Option{
+		Option(run.Env("GOGC", "50")),
+		SkipTests(),
+		RunOptions(run.Env("GOFLAGS", "-mod=mod")),
+	})
+	require.Equal(t, false, cfg.Tests)
+
+	cmd := exec.Command("true")
+	for _, opt := range runOpts(cfg) {
+		require.NoError(t, opt(context.Background(), cmd))
+	}
+	require.Contains(t, strings.Join(cmd.Env, " "), "GOGC=50")
+	require.Contains(t, strings.Join(cmd.Env, " "), "GOFLAGS=-mod=mod")
+}
+
+func Test_CheckHygiene(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "LARGE.bin"), nil, 0o600))
+
+	noLargeFiles := func(root string) error {
+		if file.Exists(filepath.Join(root, "LARGE.bin")) {
+			return errors.New("large file found")
+		}
+		return nil
+	}
+	cfg := newConfig([]Option{HygieneRules(noLargeFiles)})
+	require.Equal(t, 2, len(cfg.HygieneRules))
+
+	file.InDir(dir, func() {
+		err := lang.Catch(func() { CheckHygiene(cfg.HygieneRules...) })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "large file found")
+
+		require.NoError(t, lang.Catch(func() { CheckHygiene(MalformedFilenames) }))
+	})
+}
+
+func Test_FormatCheck(t *testing.T) {
+	dir := require.GitRepo(t)
+	unformatted := "package main\nfunc  main(){}\n"
+	require.GitCommit(t, dir, "add main", map[string]string{"main.go": unformatted})
+
+	formatted := false
+	cfg := newConfig([]Option{Formatters(Gofmt, Formatter{
+		Name:   "no-diff",
+		Format: func(_ Config) { formatted = true },
+	})})
+
+	file.InDir(dir, func() {
+		err := lang.Catch(func() { FormatCheck(cfg) })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not formatted according to: gofmt;")
+		require.Equal(t, unformatted, file.Read("main.go"))
+		require.True(t, !formatted)
+
+		Gofmt.Format(cfg)
+		require.NoError(t, lang.Catch(func() { FormatCheck(cfg) }))
+
+		require.NoError(t, os.WriteFile("main.go", []byte("package main\nfunc main() {"), 0o600))
+		err = lang.Catch(func() { FormatCheck(cfg) })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "gofmt -d -s . failed")
+	})
+}
@@ -0,0 +1,54 @@
+package golint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HygieneRule checks the repository rooted at the directory, returning an error describing any violations
+type HygieneRule func(root string) error
+
+// CheckHygiene runs the rules in the current directory, failing with the errors of all rules
+func CheckHygiene(rules ...HygieneRule) {
+	var errs []error
+	for _, rule := range rules {
+		errs = append(errs, rule("."))
+	}
+	if err := errors.Join(errs...); err != nil {
+		panic(err)
+	}
+}
+
+// MalformedFilenames fails on filenames which are not supported on all platforms, such as names containing ':'
+func MalformedFilenames(root string) error {
+	var malformedFilenames []string
+
+	err := filepath.Walk(root, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// check if the filename contains the ':' character
+		if strings.Contains(path, ":") {
+			malformedFilenames = append(malformedFilenames, path)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("error walking through files: %w", err)
+	}
+
+	if len(malformedFilenames) > 0 {
+		fmt.Println("\nfound unsupported filename characters:")
+		for _, filename := range malformedFilenames {
+			fmt.Println(filename)
+		}
+		return fmt.Errorf("\nerror: unsupported filename characters found")
+	}
+
+	return nil
+}
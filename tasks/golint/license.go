@@ -1,15 +1,187 @@
 package golint
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
 	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/template"
 )
 
-func CheckLicensesTask() Task {
+// ThirdPartyLicensesFile is the license notices file written by the ThirdPartyLicensesTask
+const ThirdPartyLicensesFile = "THIRD_PARTY_LICENSES"
+
+var licenseFilePattern = regexp.MustCompile(`(?i)^(licen[cs]e|copying|notice)([.-].*)?$`)
+
+// LicensePolicy is the configuration for bouncer license checks; without a policy, bouncer reads the
+// project's .bouncer.yaml
+type LicensePolicy struct {
+	// Permit are regular expressions of allowed license identifiers, e.g. MIT.*
+	Permit []string `yaml:"permit,omitempty"`
+	// Forbid are regular expressions of disallowed license identifiers, e.g. GPL.*
+	Forbid []string `yaml:"forbid,omitempty"`
+	// IgnorePackages are packages excluded from license checks, such as packages with licenses that
+	// are not detected correctly
+	IgnorePackages []string `yaml:"ignore-packages,omitempty"`
+}
+
+// DefaultLicensePolicy permits common permissive licenses
+var DefaultLicensePolicy = LicensePolicy{
+	Permit: []string{"BSD.*", "MIT.*", "Apache.*", "MPL.*", "ISC"},
+	IgnorePackages: []string{
+		// crypto/internal/boring is released under the openSSL license as a part of the Golang Standard Libary
+		"crypto/internal/boring",
+	},
+}
+
+// LicenseExceptions adds packages excluded from license checks to the policy, e.g. modules with licenses
+// that are not detected correctly
+func (p LicensePolicy) LicenseExceptions(packages ...string) LicensePolicy {
+	p.IgnorePackages = append(slices.Clone(p.IgnorePackages), packages...)
+	return p
+}
+
+func CheckLicensesTask(options ...Option) Task {
+	cfg := newConfig(options)
 	return Task{
 		Name:        "check-licenses",
 		Description: "ensure dependencies have allowable licenses",
 		Run: func() {
-			Run(`bouncer check ./...`)
+			CheckLicenses(cfg)
+		},
+	}
+}
+
+// CheckLicenses runs bouncer with the LicensePolicy, if configured
+func CheckLicenses(cfg Config) {
+	var args []string
+	if cfg.LicensePolicy != nil {
+		args = append(args, "--config", lang.Return(writeBouncerConfig(*cfg.LicensePolicy)))
+	}
+	Run(`bouncer check ./...`, runOpts(cfg, args...)...)
+}
+
+// ThirdPartyLicensesTask writes the license notices of all modules the packages in the module depend on
+// to the ThirdPartyLicensesFile
+func ThirdPartyLicensesTask() Task {
+	return Task{
+		Name:        "third-party-licenses",
+		Description: "write license notices of third party dependencies to " + ThirdPartyLicensesFile,
+		Run: func() {
+			file.Write(ThirdPartyLicensesFile, ThirdPartyLicenses())
+			Log("Wrote %s", ThirdPartyLicensesFile)
 		},
+		Tasks: []Task{
+			{
+				Name:   "third-party-licenses:clean",
+				RunsOn: lang.List("clean"),
+				Run: func() {
+					file.Delete(ThirdPartyLicensesFile)
+				},
+			},
+		},
+	}
+}
+
+// ThirdPartyLicenses returns the license notices of the modules the packages in the module depend on, excluding
+// the main module and the standard library
+func ThirdPartyLicenses() string {
+	type module struct {
+		Path    string
+		Version string
+		Dir     string
+		Main    bool
+		Replace *module
+	}
+	type pkg struct {
+		Module *module
+	}
+
+	modules := map[string]module{}
+	dec := json.NewDecoder(strings.NewReader(Run("go list -deps -json ./...", run.Quiet())))
+	for {
+		var p pkg
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		lang.Throw(err)
+		if p.Module == nil || p.Module.Main {
+			continue
+		}
+		m := *p.Module
+		if m.Replace != nil {
+			m.Dir = lang.Default(m.Replace.Dir, m.Dir)
+			m.Version = lang.Default(m.Replace.Version, m.Version)
+		}
+		modules[m.Path] = m
+	}
+
+	contents := strings.Builder{}
+	for _, path := range slices.Sorted(maps.Keys(modules)) {
+		m := modules[path]
+		contents.WriteString(strings.Repeat("=", 80) + "\n")
+		contents.WriteString(strings.TrimSpace(m.Path+" "+m.Version) + "\n")
+		contents.WriteString(strings.Repeat("-", 80) + "\n")
+		licenses := licenseFiles(m.Dir)
+		if len(licenses) == 0 {
+			log.Warn("no license file found for %s in %s", m.Path, m.Dir)
+			contents.WriteString("no license file found\n\n")
+			continue
+		}
+		for _, f := range licenses {
+			contents.WriteString(strings.TrimSpace(file.Read(f)) + "\n\n")
+		}
+	}
+	return contents.String()
+}
+
+// licenseFiles returns the license and notice files in the root of the module directory
+func licenseFiles(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Debug("unable to read module dir %s: %v", dir, err)
+		return nil
+	}
+	var out []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && licenseFilePattern.MatchString(entry.Name()) {
+			out = append(out, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return out
+}
+
+func writeBouncerConfig(policy LicensePolicy) (string, error) {
+	contents, err := yaml.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(template.Render(config.TmpDir), "bouncer-config")
+	if err != nil {
+		return "", err
 	}
+	config.OnExit(func() {
+		log.Error(os.RemoveAll(tmpDir))
+	})
+	configFile := filepath.Join(tmpDir, ".bouncer.yaml")
+	log.Debug("bouncer config %s:\n%s", configFile, contents)
+	return configFile, os.WriteFile(configFile, contents, 0o600)
 }
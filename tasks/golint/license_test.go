@@ -0,0 +1,80 @@
+package golint
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Licenses(t *testing.T) {
+	policy := DefaultLicensePolicy.LicenseExceptions("example.com/mislabeled")
+	policy.Forbid = []string{"GPL.*"}
+	cfg := newConfig([]Option{Licenses(policy)})
+
+	configFile, err := writeBouncerConfig(*cfg.LicensePolicy)
+	require.NoError(t, err)
+	require.Equal(t, ".bouncer.yaml", filepath.Base(configFile))
+
+	contents := file.Read(configFile)
+	require.Contains(t, contents, "permit:")
+	require.Contains(t, contents, "- GPL.*")
+	require.Contains(t, contents, "- crypto/internal/boring")
+	require.Contains(t, contents, "- example.com/mislabeled")
+
+	// exceptions do not modify the original policy
+	require.Equal(t, 1, len(DefaultLicensePolicy.IgnorePackages))
+}
+
+func Test_ThirdPartyLicenses(t *testing.T) {
+	dir := require.GitRepo(t)
+	for _, module := range []string{"app", "dep", "unlicensed"} {
+		file.EnsureDir(filepath.Join(dir, module))
+	}
+	file.Write(filepath.Join(dir, "dep", "go.mod"), "module example.com/dep\n\ngo 1.21\n")
+	file.Write(filepath.Join(dir, "dep", "dep.go"), "package dep\n\nconst Name = \"dep\"\n")
+	file.Write(filepath.Join(dir, "dep", "LICENSE.md"), "the dep license\n")
+	file.Write(filepath.Join(dir, "dep", "NOTICE"), "the dep notice\n")
+	file.Write(filepath.Join(dir, "dep", "licenses.go"), "package dep\n")
+	file.Write(filepath.Join(dir, "unlicensed", "go.mod"), "module example.com/unlicensed\n\ngo 1.21\n")
+	file.Write(filepath.Join(dir, "unlicensed", "unlicensed.go"), "package unlicensed\n\nconst Name = \"unlicensed\"\n")
+	file.Write(filepath.Join(dir, "app", "go.mod"), `module example.com/app
+
+go 1.21
+
+require (
+	example.com/dep v1.0.0
+	example.com/unlicensed v1.0.0
+)
+
+replace example.com/dep => ../dep
+
+replace example.com/unlicensed => ../unlicensed
+`)
+	file.Write(filepath.Join(dir, "app", "LICENSE"), "the app license\n")
+	file.Write(filepath.Join(dir, "app", "main.go"), `package main
+
+import (
+	"example.com/dep"
+	"example.com/unlicensed"
+)
+
+func main() {
+	println(dep.Name, unlicensed.Name)
+}
+`)
+
+	file.InDir(filepath.Join(dir, "app"), func() {
+		contents := ThirdPartyLicenses()
+
+		require.True(t, !strings.Contains(contents, "the app license"))
+		depIdx := strings.Index(contents, "example.com/dep")
+		unlicensedIdx := strings.Index(contents, "example.com/unlicensed")
+		require.True(t, depIdx >= 0 && unlicensedIdx > depIdx)
+		require.Contains(t, contents, "the dep license\n\nthe dep notice\n")
+		require.True(t, !strings.Contains(contents, "package dep"))
+		require.Contains(t, contents[unlicensedIdx:], "no license file found")
+	})
+}
@@ -3,7 +3,6 @@ package goreleaser
 import (
 	"errors"
 	"os"
-	"strings"
 
 	. "github.com/anchore/go-make"
 	"github.com/anchore/go-make/binny"
@@ -11,16 +10,21 @@ import (
 	"github.com/anchore/go-make/log"
 	"github.com/anchore/go-make/run"
 	"github.com/anchore/go-make/tasks/release"
+	"github.com/anchore/go-make/version"
 )
 
 const configName = ".goreleaser.yaml"
 
+// releaseTools are the tools used to release, verified to be installable by the release preflight checks
+var releaseTools = []string{"goreleaser", "quill", "syft"}
+
 func Tasks() Task {
 	return Task{
 		Tasks: []Task{
 			SnapshotTasks(),
 			CIReleaseTask(),
 			release.WorkflowReleaseTask(),
+			release.PreflightTask(release.ReleaseTools(releaseTools...)),
 		},
 	}
 }
@@ -48,16 +52,12 @@ func CIReleaseTask() Task {
 }
 
 func ensureHeadHasTag() {
-	tags := strings.Split(Run("git tag --points-at HEAD"), "\n")
-
-	for _, tag := range tags {
-		if strings.HasPrefix(tag, "v") {
-			log.Info("HEAD has a version tag: %s", tag)
-			return
-		}
+	if tag, ok := version.Tag(); ok {
+		log.Info("HEAD has a version tag: %s", tag)
+		return
 	}
 
-	panic(errors.New("HEAD does not have a tag that starts with 'v'"))
+	panic(errors.New("HEAD does not have a version tag, e.g. v1.2.3"))
 }
 
 func failIfNotInCI() {
@@ -0,0 +1,113 @@
+package gotest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/changes"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+)
+
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// affectedPackages filters the selected packages to those affected by the changed files: packages containing changed
+// files and all packages that depend on them, including through test imports
+func affectedPackages(selected []string) []string {
+	files, err := changes.Files()
+	if err != nil {
+		log.Debug("unable to determine changed files, testing all packages: %v", err)
+		return selected
+	}
+
+	root := git.Root()
+	var changedPaths []string
+	for _, f := range files {
+		changedPaths = append(changedPaths, filepath.Join(root, filepath.FromSlash(f)))
+	}
+
+	selectedPackages := strings.Fields(Run("go list", run.Args(selected...), run.Quiet()))
+	allPackages := listPackages(Run("go list -json ./...", run.Quiet()))
+
+	affected := findAffected(allPackages, changedPaths)
+	if affected == nil {
+		log.Debug("module files changed, testing all packages")
+		return selected
+	}
+
+	out := lang.Remove(selectedPackages, func(pkg string) bool {
+		return !affected[pkg]
+	})
+	log.Debug("packages affected by changes: %v", out)
+	return out
+}
+
+// findAffected returns the import paths of all packages affected by the changed paths, or nil if all packages are
+// affected, such as when go.mod changes
+func findAffected(packages []goPackage, changedPaths []string) map[string]bool {
+	affected := map[string]bool{}
+	for _, changed := range changedPaths {
+		switch filepath.Base(changed) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum":
+			return nil
+		}
+		// attribute changes to the nearest package directory, which includes testdata, etc.
+		for dir := filepath.Dir(changed); ; dir = filepath.Dir(dir) {
+			idx := slices.IndexFunc(packages, func(p goPackage) bool {
+				return p.Dir == dir
+			})
+			if idx >= 0 {
+				affected[packages[idx].ImportPath] = true
+				break
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+
+	// Deps are transitive for regular imports, but test imports are direct, so iterate until nothing is added
+	for added := true; added; {
+		added = false
+		for _, p := range packages {
+			if affected[p.ImportPath] {
+				continue
+			}
+			for _, dep := range slices.Concat(p.Deps, p.TestImports, p.XTestImports) {
+				if affected[dep] {
+					affected[p.ImportPath] = true
+					added = true
+					break
+				}
+			}
+		}
+	}
+	return affected
+}
+
+func listPackages(goListJSON string) []goPackage {
+	var out []goPackage
+	dec := json.NewDecoder(strings.NewReader(goListJSON))
+	for {
+		var p goPackage
+		err := dec.Decode(&p)
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		lang.Throw(err)
+		out = append(out, p)
+	}
+}
@@ -0,0 +1,90 @@
+package gotest
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/anchore/go-make/require"
+)
+
+func Test_findAffected(t *testing.T) {
+	root := filepath.FromSlash("/src/mod")
+	pkg := func(importPath string, deps, testImports []string) goPackage {
+		return goPackage{
+			ImportPath:  "example.com/mod/" + importPath,
+			Dir:         filepath.Join(root, filepath.FromSlash(importPath)),
+			Deps:        prefixed(deps),
+			TestImports: prefixed(testImports),
+		}
+	}
+	packages := []goPackage{
+		pkg("lib", nil, nil),
+		pkg("util", nil, nil),
+		pkg("cmd", []string{"lib"}, nil),
+		pkg("app", []string{"cmd", "lib"}, nil),
+		pkg("other", []string{"util"}, []string{"cmd"}),
+		pkg("nested/deep", nil, nil),
+	}
+
+	tests := []struct {
+		name     string
+		changed  []string
+		expected []string
+	}{
+		{
+			name:     "docs only",
+			changed:  []string{"README.md", "docs/guide.md"},
+			expected: []string{},
+		},
+		{
+			name:     "reverse dependencies",
+			changed:  []string{"lib/lib.go"},
+			expected: []string{"lib", "cmd", "app", "other"},
+		},
+		{
+			name:     "testdata attributed to package",
+			changed:  []string{"util/testdata/fixture.json"},
+			expected: []string{"util", "other"},
+		},
+		{
+			name:     "nested package",
+			changed:  []string{"nested/deep/file.go"},
+			expected: []string{"nested/deep"},
+		},
+		{
+			name:    "module changes affect everything",
+			changed: []string{"util/util.go", "go.sum"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changed []string
+			for _, f := range tt.changed {
+				changed = append(changed, filepath.Join(root, filepath.FromSlash(f)))
+			}
+			affected := findAffected(packages, changed)
+			if tt.expected == nil {
+				require.True(t, affected == nil)
+				return
+			}
+			var got []string
+			for importPath := range affected {
+				got = append(got, importPath)
+			}
+			slices.Sort(got)
+			expected := prefixed(tt.expected)
+			slices.Sort(expected)
+			require.EqualElements(t, expected, got)
+		})
+	}
+}
+
+func prefixed(paths []string) []string {
+	out := []string{}
+	for _, p := range paths {
+		out = append(out, "example.com/mod/"+p)
+	}
+	return out
+}
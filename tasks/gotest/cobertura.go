@@ -0,0 +1,131 @@
+package gotest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+)
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      int                `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity int              `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity int             `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura writes the profile as a Cobertura XML report with line coverage, each file is reported as a class
+// with a filename relative to the module root
+func WriteCobertura(reportFile string, profile Profile) {
+	modulePath := ""
+	source := "."
+	if gm := gomod.Read(); gm != nil && gm.Module != nil {
+		modulePath = gm.Module.Mod.Path
+		source = gomod.Root()
+	}
+
+	// the hits of each line are the maximum count of the blocks including the line
+	fileLines := map[string]map[int]int{}
+	for _, b := range profile.Blocks {
+		lines := fileLines[b.File]
+		if lines == nil {
+			lines = map[int]int{}
+			fileLines[b.File] = lines
+		}
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			lines[line] = max(lines[line], b.Count)
+		}
+	}
+
+	report := coberturaCoverage{
+		BranchRate: "0",
+		Timestamp:  time.Now().Unix(),
+		Sources:    []string{source},
+	}
+	packages := map[string]*coberturaPackage{}
+	packageLines := map[string][2]int{}
+	for _, f := range slices.Sorted(maps.Keys(fileLines)) {
+		lines := fileLines[f]
+		class := coberturaClass{
+			Name:       path.Base(f),
+			Filename:   filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(f, modulePath), "/")),
+			BranchRate: "0",
+		}
+		covered := 0
+		for _, line := range slices.Sorted(maps.Keys(lines)) {
+			class.Lines = append(class.Lines, coberturaLine{Number: line, Hits: lines[line]})
+			if lines[line] > 0 {
+				covered++
+			}
+		}
+		class.LineRate = rate(covered, len(lines))
+
+		pkgName := path.Dir(f)
+		pkg := packages[pkgName]
+		if pkg == nil {
+			pkg = &coberturaPackage{Name: pkgName, BranchRate: "0"}
+			packages[pkgName] = pkg
+		}
+		pkg.Classes = append(pkg.Classes, class)
+		counts := packageLines[pkgName]
+		packageLines[pkgName] = [2]int{counts[0] + covered, counts[1] + len(lines)}
+		report.LinesCovered += covered
+		report.LinesValid += len(lines)
+	}
+	for _, name := range slices.Sorted(maps.Keys(packages)) {
+		pkg := packages[name]
+		pkg.LineRate = rate(packageLines[name][0], packageLines[name][1])
+		report.Packages = append(report.Packages, *pkg)
+	}
+	report.LineRate = rate(report.LinesCovered, report.LinesValid)
+
+	contents := lang.Return(xml.MarshalIndent(report, "", "  "))
+	file.EnsureDir(filepath.Dir(reportFile))
+	file.Write(reportFile, xml.Header+string(contents)+"\n")
+}
+
+func rate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return fmt.Sprintf("%.4f", float64(covered)/float64(valid))
+}
@@ -0,0 +1,328 @@
+package gotest
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+)
+
+// MergedCoverageFile is the profile written to the CoverageDir by the CoverageTask
+const MergedCoverageFile = "coverage.out"
+
+// CoverageTask merges the coverage profiles written to the CoverageDir by the MergeCoverage test tasks and reports
+// coverage of the merged profile, e.g.:
+//
+//	gotest.Tasks(gotest.CoverageDir(".tmp/coverage")),
+//	gotest.Tasks(gotest.Name("integration"), gotest.CoverageDir(".tmp/coverage")),
+//	gotest.CoverageTask(gotest.CoverageDir(".tmp/coverage"), gotest.MergeCoverage("unit", "integration"), gotest.MinCoverage(80)),
+func CoverageTask(options ...Option) Task {
+	cfg := defaultConfig()
+	cfg.Name = "coverage"
+	cfg.CoverageArtifact = "code-coverage-merged"
+	cfg.MergeCoverage = lang.List("unit")
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return Task{
+		Name:         cfg.Name,
+		Description:  fmt.Sprintf("merge and report coverage of %s tests", strings.Join(cfg.MergeCoverage, ", ")),
+		Dependencies: cfg.MergeCoverage,
+		Paths:        cfg.Paths,
+		Run: func() {
+			if cfg.CoverageDir == "" {
+				panic(fmt.Errorf("a CoverageDir is required to merge coverage profiles"))
+			}
+			var profiles []Profile
+			for _, name := range cfg.MergeCoverage {
+				profileFile := filepath.Join(cfg.CoverageDir, name+".out")
+				if !file.Exists(profileFile) {
+					log.Warn("no coverage profile for %s tests: %s", name, profileFile)
+					continue
+				}
+				profiles = append(profiles, ReadProfile(profileFile))
+			}
+			merged := filepath.Join(cfg.CoverageDir, MergedCoverageFile)
+			MergeProfiles(profiles...).Write(merged)
+			ReportCoverage(cfg, merged)
+		},
+	}
+}
+
+// ProfileBlock is a block of statements in a coverage profile
+type ProfileBlock struct {
+	// File is the import path of the file, e.g. github.com/anchore/go-make/run/run.go
+	File       string
+	StartLine  int
+	StartCol   int
+	EndLine    int
+	EndCol     int
+	Statements int
+	Count      int
+}
+
+// Profile is a coverage profile written by `go test -coverprofile`
+type Profile struct {
+	Mode   string
+	Blocks []ProfileBlock
+}
+
+var profileLinePattern = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// ReadProfile reads a coverage profile, merging duplicate blocks, such as blocks reported by multiple test binaries
+// when using -coverpkg
+func ReadProfile(profileFile string) Profile {
+	var p Profile
+	for i, line := range strings.Split(file.Read(profileFile), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if mode, ok := strings.CutPrefix(line, "mode: "); ok {
+			p.Mode = lang.Default(p.Mode, mode)
+			continue
+		}
+		m := profileLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			panic(fmt.Errorf("invalid coverage profile line %s:%d: %q", profileFile, i+1, line))
+		}
+		n := lang.Map(m[2:], func(s string) int { return lang.Return(strconv.Atoi(s)) })
+		p.Blocks = append(p.Blocks, ProfileBlock{File: m[1], StartLine: n[0], StartCol: n[1], EndLine: n[2], EndCol: n[3], Statements: n[4], Count: n[5]})
+	}
+	return MergeProfiles(p)
+}
+
+// MergeProfiles merges coverage profiles: counts of the same block are added, or in set mode, a block is covered if
+// covered in any profile
+func MergeProfiles(profiles ...Profile) Profile {
+	out := Profile{Mode: "set"}
+	index := map[ProfileBlock]int{}
+	for _, p := range profiles {
+		if p.Mode != "" && p.Mode != "set" {
+			out.Mode = p.Mode
+		}
+		for _, b := range p.Blocks {
+			count := b.Count
+			b.Count = 0
+			idx, ok := index[b]
+			if !ok {
+				idx = len(out.Blocks)
+				index[b] = idx
+				out.Blocks = append(out.Blocks, b)
+			}
+			out.Blocks[idx].Count += count
+		}
+	}
+	if out.Mode == "set" {
+		for i := range out.Blocks {
+			out.Blocks[i].Count = min(out.Blocks[i].Count, 1)
+		}
+	}
+	slices.SortStableFunc(out.Blocks, func(a, b ProfileBlock) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine - b.StartLine
+		}
+		return a.StartCol - b.StartCol
+	})
+	return out
+}
+
+// Write writes the profile in the `go test -coverprofile` format
+func (p Profile) Write(profileFile string) {
+	sb := strings.Builder{}
+	sb.WriteString("mode: " + lang.Default(p.Mode, "set") + "\n")
+	for _, b := range p.Blocks {
+		sb.WriteString(fmt.Sprintf("%s:%d.%d,%d.%d %d %d\n", b.File, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.Statements, b.Count))
+	}
+	file.EnsureDir(filepath.Dir(profileFile))
+	file.Write(profileFile, sb.String())
+}
+
+// Coverage is the number of covered statements
+type Coverage struct {
+	Statements int
+	Covered    int
+}
+
+// Percent returns the percentage of covered statements, 100 when there are no statements
+func (c Coverage) Percent() float64 {
+	if c.Statements == 0 {
+		return 100
+	}
+	return float64(c.Covered) * 100 / float64(c.Statements)
+}
+
+// Total returns the coverage of all statements in the profile
+func (p Profile) Total() Coverage {
+	var out Coverage
+	for _, b := range p.Blocks {
+		out = out.add(b)
+	}
+	return out
+}
+
+// Packages returns the coverage by package import path
+func (p Profile) Packages() map[string]Coverage {
+	out := map[string]Coverage{}
+	for _, b := range p.Blocks {
+		pkg := path.Dir(b.File)
+		out[pkg] = out[pkg].add(b)
+	}
+	return out
+}
+
+func (c Coverage) add(b ProfileBlock) Coverage {
+	c.Statements += b.Statements
+	if b.Count > 0 {
+		c.Covered += b.Statements
+	}
+	return c
+}
+
+// ReportCoverage logs the coverage of the profile, writes the configured reports, uploads the profile as the
+// CoverageArtifact when running in GitHub Actions, compares coverage to the base branch and fails if coverage is below the configured minimums
+func ReportCoverage(cfg Config, profileFile string) {
+	profile := ReadProfile(profileFile)
+	total := profile.Total()
+
+	if cfg.Verbose {
+		Log(" -------------- Coverage Report -------------- ")
+		Log(Run("go tool cover", run.Args("-func", profileFile), run.Quiet()))
+	}
+	Log("Coverage: %.1f%%", total.Percent())
+
+	if cfg.CoverageHTML != "" {
+		file.EnsureDir(filepath.Dir(cfg.CoverageHTML))
+		Run("go tool cover", run.Args("-html", profileFile, "-o", cfg.CoverageHTML), run.Quiet())
+		log.Debug("wrote coverage HTML report: %s", cfg.CoverageHTML)
+	}
+	if cfg.CoverageCobertura != "" {
+		WriteCobertura(cfg.CoverageCobertura, profile)
+		log.Debug("wrote coverage Cobertura report: %s", cfg.CoverageCobertura)
+	}
+
+	if config.OS == "linux" {
+		err := lang.Catch(func() {
+			lang.Return(github.NewClient().UploadArtifactDir(filepath.Dir(profileFile), github.UploadArtifactOption{
+				ArtifactName: coverageArtifactName(cfg),
+				Overwrite:    false, // we only need one, failures are logged but ignored
+				Files:        []string{profileFile},
+			}))
+		})
+		if err != nil {
+			log.Debug("error uploading coverage file: %v", err)
+		}
+	}
+
+	if cfg.CompareCoverage {
+		compareCoverage(cfg, profile)
+	}
+
+	lang.Throw(checkCoverage(cfg, profile))
+}
+
+func coverageArtifactName(cfg Config) string {
+	if cfg.CoverageArtifactPerJob {
+		return cfg.CoverageArtifact + github.MatrixSuffix
+	}
+	return cfg.CoverageArtifact
+}
+
+// checkCoverage returns an error if the total or any package coverage is below the configured minimums
+func checkCoverage(cfg Config, profile Profile) error {
+	var errs []string
+	if total := profile.Total().Percent(); total < cfg.MinCoverage {
+		errs = append(errs, fmt.Sprintf("total coverage %.1f%% is below the minimum %.1f%%", total, cfg.MinCoverage))
+	}
+	if cfg.MinPackageCoverage > 0 {
+		packages := profile.Packages()
+		for _, pkg := range slices.Sorted(maps.Keys(packages)) {
+			if pct := packages[pkg].Percent(); pct < cfg.MinPackageCoverage {
+				errs = append(errs, fmt.Sprintf("%s coverage %.1f%% is below the minimum %.1f%%", pkg, pct, cfg.MinPackageCoverage))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("coverage check failed:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// compareCoverage logs the change in coverage from the base branch, failing if coverage decreased more than
+// MaxCoverageDecrease; the comparison is skipped if the base coverage is not available
+func compareCoverage(cfg Config, profile Profile) {
+	branch, base, err := baseCoverage(cfg)
+	if err != nil {
+		log.Warn("unable to compare coverage to the base branch: %v", err)
+		return
+	}
+	total := profile.Total().Percent()
+	delta := total - base.Total().Percent()
+	Log("Coverage: %.1f%% (%+.1f%% compared to %s)", total, delta, branch)
+
+	basePackages := base.Packages()
+	for pkg, c := range profile.Packages() {
+		if b, ok := basePackages[pkg]; ok && c.Percent() < b.Percent() {
+			Log("  %s: %.1f%% (%+.1f%%)", pkg, c.Percent(), c.Percent()-b.Percent())
+		}
+	}
+	if cfg.MaxCoverageDecrease >= 0 && -delta > cfg.MaxCoverageDecrease {
+		panic(fmt.Errorf("coverage decreased %.1f%% compared to %s, more than the maximum %.1f%%", -delta, branch, cfg.MaxCoverageDecrease))
+	}
+}
+
+// baseCoverage downloads the coverage artifact from the latest successful run of the workflow on the base branch
+var baseCoverage = func(cfg Config) (branch string, profile Profile, err error) {
+	dir, err := os.MkdirTemp(config.TmpDir, "base-coverage-")
+	if err != nil {
+		return "", profile, err
+	}
+	defer func() {
+		log.Error(os.RemoveAll(dir))
+	}()
+
+	branch, err = downloadBaseArtifacts(coverageArtifactName(cfg), dir)
+	if err != nil {
+		return branch, profile, err
+	}
+	var profiles []Profile
+	for _, f := range file.FindAll(filepath.Join(dir, "**", "*.out")) {
+		profiles = append(profiles, ReadProfile(f))
+	}
+	if len(profiles) == 0 {
+		return branch, profile, fmt.Errorf("no coverage profile found in the %s artifact", cfg.CoverageArtifact)
+	}
+	return branch, MergeProfiles(profiles...), nil
+}
+
+// downloadBaseArtifacts downloads the artifacts matching the name glob from the latest successful run of the workflow
+// on the base branch of the pull request, or the default branch, to the dir
+var downloadBaseArtifacts = func(artifactNameGlob, dir string) (branch string, err error) {
+	branch = os.Getenv("GITHUB_BASE_REF")
+	if branch == "" {
+		if branch, err = git.DefaultBranch("origin"); err != nil {
+			return "", err
+		}
+	}
+	return branch, lang.Catch(func() {
+		lang.Throw(github.NewClient().DownloadBranchArtifactDir(branch, os.Getenv("GITHUB_WORKFLOW"), artifactNameGlob, dir))
+	})
+}
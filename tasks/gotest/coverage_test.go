@@ -0,0 +1,156 @@
+package gotest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+const unitProfile = `mode: atomic
+example.com/cov/a/a.go:3.14,5.2 2 4
+example.com/cov/a/a.go:7.14,9.2 2 0
+example.com/cov/b/b.go:3.14,4.2 1 0
+example.com/cov/a/a.go:3.14,5.2 2 1
+`
+
+const integrationProfile = `mode: atomic
+example.com/cov/a/a.go:7.14,9.2 2 3
+example.com/cov/b/b.go:3.14,4.2 1 0
+`
+
+func Test_MergeProfiles(t *testing.T) {
+	dir := t.TempDir()
+	file.Write(filepath.Join(dir, "unit.out"), unitProfile)
+	file.Write(filepath.Join(dir, "integration.out"), integrationProfile)
+
+	// duplicate blocks within a profile are merged
+	unit := ReadProfile(filepath.Join(dir, "unit.out"))
+	require.Equal(t, 3, len(unit.Blocks))
+	require.Equal(t, 5, unit.Blocks[0].Count)
+	require.Equal(t, Coverage{Statements: 5, Covered: 2}, unit.Total())
+
+	merged := MergeProfiles(unit, ReadProfile(filepath.Join(dir, "integration.out")))
+	require.Equal(t, "atomic", merged.Mode)
+	require.Equal(t, Coverage{Statements: 5, Covered: 4}, merged.Total())
+	require.Equal(t, map[string]Coverage{
+		"example.com/cov/a": {Statements: 4, Covered: 4},
+		"example.com/cov/b": {Statements: 1, Covered: 0},
+	}, merged.Packages())
+
+	merged.Write(filepath.Join(dir, "merged.out"))
+	require.Equal(t, `mode: atomic
+example.com/cov/a/a.go:3.14,5.2 2 5
+example.com/cov/a/a.go:7.14,9.2 2 3
+example.com/cov/b/b.go:3.14,4.2 1 0
+`, file.Read(filepath.Join(dir, "merged.out")))
+
+	cfg := defaultConfig()
+	require.NoError(t, checkCoverage(cfg, merged))
+
+	MinCoverage(80)(&cfg)
+	require.NoError(t, checkCoverage(cfg, merged))
+
+	MinCoverage(90)(&cfg)
+	MinPackageCoverage(50)(&cfg)
+	err := checkCoverage(cfg, merged)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "total coverage 80.0% is below the minimum 90.0%")
+	require.Contains(t, err.Error(), "example.com/cov/b coverage 0.0% is below the minimum 50.0%")
+}
+
+func Test_compareCoverage(t *testing.T) {
+	base := Profile{Mode: "set", Blocks: []ProfileBlock{
+		{File: "example.com/cov/a/a.go", StartLine: 1, EndLine: 2, Statements: 3, Count: 1},
+		{File: "example.com/cov/a/a.go", StartLine: 3, EndLine: 4, Statements: 1, Count: 1},
+	}}
+	current := Profile{Mode: "set", Blocks: []ProfileBlock{
+		{File: "example.com/cov/a/a.go", StartLine: 1, EndLine: 2, Statements: 3, Count: 1},
+		{File: "example.com/cov/a/a.go", StartLine: 3, EndLine: 4, Statements: 1, Count: 0},
+	}}
+	require.SetAndRestore(t, &baseCoverage, func(_ Config) (string, Profile, error) {
+		return "main", base, nil
+	})
+
+	cfg := defaultConfig()
+	CompareCoverage(-1)(&cfg)
+	require.NoError(t, lang.Catch(func() { compareCoverage(cfg, current) }))
+
+	CompareCoverage(30)(&cfg)
+	require.NoError(t, lang.Catch(func() { compareCoverage(cfg, current) }))
+
+	CompareCoverage(10)(&cfg)
+	err := lang.Catch(func() { compareCoverage(cfg, current) })
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "coverage decreased 25.0% compared to main")
+}
+
+func Test_coverageArtifactName(t *testing.T) {
+	require.SetAndRestore(t, &github.MatrixSuffix, "_linux_2")
+
+	cfg := defaultConfig()
+	require.Equal(t, "code-coverage", coverageArtifactName(cfg))
+
+	CoverageArtifactPerJob()(&cfg)
+	require.Equal(t, "code-coverage_linux_2", coverageArtifactName(cfg))
+}
+
+func Test_ReportCoverage(t *testing.T) {
+	require.SetAndRestore(t, &config.OS, "test") // no artifact upload
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "add module", map[string]string{
+		"go.mod":      "module example.com/cov\n\ngo 1.21\n",
+		"a/a.go":      "package a\n\nfunc Covered() int {\n\treturn 1\n}\n\nfunc Uncovered() int {\n\treturn 2\n}\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestCovered(t *testing.T) {\n\tCovered()\n}\n",
+	})
+
+	file.InDir(dir, func() {
+		cfg := defaultConfig()
+		CoverageDir(filepath.Join("reports", "coverage"))(&cfg)
+		CoverageHTML(filepath.Join("reports", "coverage.html"))(&cfg)
+		CoverageCobertura(filepath.Join("reports", "cobertura.xml"))(&cfg)
+
+		profileFile := filepath.Join(cfg.CoverageDir, cfg.Name+".out")
+		file.EnsureDir(cfg.CoverageDir)
+		_, err := runTests(cfg, []string{"test", "-json", "-coverprofile", profileFile, "./..."})
+		require.NoError(t, err)
+
+		ReportCoverage(cfg, profileFile)
+		require.Contains(t, file.Read(cfg.CoverageHTML), "Uncovered")
+
+		cobertura := file.Read(cfg.CoverageCobertura)
+		require.Contains(t, cobertura, `<coverage line-rate="0.5000" branch-rate="0" lines-covered="2" lines-valid="4"`)
+		require.Contains(t, cobertura, `<source>`+dir+`</source>`)
+		require.Contains(t, cobertura, `<class name="a.go" filename="a/a.go" line-rate="0.5000"`)
+		require.Contains(t, cobertura, `<line number="4" hits="1"></line>`)
+		require.Contains(t, cobertura, `<line number="8" hits="0"></line>`)
+
+		MinCoverage(75)(&cfg)
+		err = lang.Catch(func() { ReportCoverage(cfg, profileFile) })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "total coverage 50.0% is below the minimum 75.0%")
+	})
+}
+
+func Test_shardCoverageIsPartial(t *testing.T) {
+	require.SetAndRestore(t, &config.OS, "test") // no artifact upload
+	t.Setenv("TEST_SHARDS", "")
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "add module", map[string]string{
+		"go.mod":      "module example.com/cov\n\ngo 1.21\n",
+		"a/a.go":      "package a\n\nfunc Uncovered() int {\n\treturn 2\n}\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestNothing(t *testing.T) {}\n",
+	})
+
+	file.InDir(dir, func() {
+		task := Tasks(Shard(1, 2), MinCoverage(100), CoverageDir("coverage"))
+		require.NoError(t, lang.Catch(task.Run))
+
+		task = Tasks(MinCoverage(100), CoverageDir("coverage"))
+		require.Error(t, lang.Catch(task.Run))
+	})
+}
@@ -0,0 +1,114 @@
+package gotest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName    string        `xml:"classname,attr"`
+	Name         string        `xml:"name,attr"`
+	Time         string        `xml:"time,attr"`
+	Failure      *junitMessage `xml:"failure,omitempty"`
+	Skipped      *junitMessage `xml:"skipped,omitempty"`
+	FlakyFailure *junitMessage `xml:"flakyFailure,omitempty"`
+	SystemOut    *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Contents string `xml:",cdata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report with a test suite for each package; packages which fail
+// without a failed test, such as build failures, are reported as a failed TestMain test case
+func WriteJUnit(path string, results []TestResult) {
+	suites := junitTestSuites{}
+	var total float64
+	var packages []string
+	for _, r := range results {
+		if !slices.Contains(packages, r.Package) {
+			packages = append(packages, r.Package)
+		}
+	}
+	failures := Failures(results)
+	for _, pkg := range packages {
+		suite := junitTestSuite{Name: pkg}
+		for _, r := range results {
+			if r.Package != pkg {
+				continue
+			}
+			if r.Name == "" {
+				suite.Time = seconds(r.Elapsed.Seconds())
+				total += r.Elapsed.Seconds()
+				if !slices.ContainsFunc(failures, func(f TestResult) bool { return f.Package == pkg && f.Name == "" }) {
+					continue
+				}
+			}
+			tc := junitTestCase{
+				ClassName: pkg,
+				Name:      lang.Default(r.Name, "TestMain"),
+				Time:      seconds(r.Elapsed.Seconds()),
+			}
+			switch r.Status {
+			case StatusFail:
+				tc.Failure = &junitMessage{Message: "Failed", Contents: r.Output}
+				// failed tests with failed subtests are not counted, consistent with the failure summary
+				if slices.ContainsFunc(failures, func(f TestResult) bool { return f.Package == pkg && f.Name == r.Name }) {
+					suite.Failures++
+				}
+			case StatusSkip:
+				tc.Skipped = &junitMessage{Message: "Skipped", Contents: r.Output}
+				suite.Skipped++
+			default:
+				tc.SystemOut = &junitOutput{Contents: r.Output}
+			}
+			if r.Flaky {
+				tc.FlakyFailure = &junitMessage{Message: fmt.Sprintf("passed after %d attempts", r.Attempts), Contents: r.FailedOutput}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+			suite.Tests++
+		}
+		suite.Time = lang.Default(suite.Time, seconds(0))
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = seconds(total)
+
+	contents := lang.Return(xml.MarshalIndent(suites, "", "  "))
+	file.EnsureDir(filepath.Dir(path))
+	file.Write(path, xml.Header+string(contents)+"\n")
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
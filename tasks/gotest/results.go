@@ -0,0 +1,193 @@
+package gotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anchore/go-make/stream"
+)
+
+// TestStatus is the outcome of a test or package
+type TestStatus string
+
+const (
+	StatusPass TestStatus = "pass"
+	StatusFail TestStatus = "fail"
+	StatusSkip TestStatus = "skip"
+)
+
+// TestEvent is an event written by `go test -json`, see `go doc test2json`
+type TestEvent struct {
+	Time       time.Time `json:"Time"`
+	Action     string    `json:"Action"`
+	Package    string    `json:"Package"`
+	ImportPath string    `json:"ImportPath"`
+	Test       string    `json:"Test"`
+	Elapsed    float64   `json:"Elapsed"`
+	Output     string    `json:"Output"`
+}
+
+// TestResult is the result of a single test, or of a package when Name is empty
+type TestResult struct {
+	Package string
+	// Name is the test name, including any subtest path, e.g. TestA/sub; empty for package results
+	Name    string
+	Status  TestStatus
+	Elapsed time.Duration
+	// Output is all output of the test, or of the package outside of any test
+	Output string
+	// Attempts is the number of times the test was run
+	Attempts int
+	// Flaky indicates the test failed and then passed when retried
+	Flaky bool
+	// FailedOutput is the output of the last failed attempt of a flaky test
+	FailedOutput string
+}
+
+// TopLevel returns the top-level test name, e.g. TestA for TestA/sub
+func (r TestResult) TopLevel() string {
+	name, _, _ := strings.Cut(r.Name, "/")
+	return name
+}
+
+// ParseTestEvents returns the results from `go test -json` output, in the order tests started
+func ParseTestEvents(r io.Reader) []TestResult {
+	c := newResultCollector(nil)
+	lines := stream.Lines(c.line)
+	_, _ = io.Copy(lines, r)
+	_ = lines.Close()
+	return c.results()
+}
+
+// resultCollector accumulates results from `go test -json` output lines, calling onEvent for each event and onOther
+// for any lines which are not events, such as build errors reported by older go versions
+type resultCollector struct {
+	byKey   map[[2]string]*TestResult
+	order   [][2]string
+	onEvent func(TestEvent)
+	onOther func(string)
+}
+
+func newResultCollector(onEvent func(TestEvent)) *resultCollector {
+	return &resultCollector{byKey: map[[2]string]*TestResult{}, onEvent: onEvent}
+}
+
+func (c *resultCollector) line(line string) {
+	var ev TestEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &ev) != nil {
+		if c.onOther != nil {
+			c.onOther(line)
+		}
+		return
+	}
+	if c.onEvent != nil {
+		c.onEvent(ev)
+	}
+
+	pkg := ev.Package
+	if ev.Action == "build-output" || ev.Action == "build-fail" {
+		// build events reference the package being built, e.g. example.com/a [example.com/a.test]
+		pkg, _, _ = strings.Cut(ev.ImportPath, " ")
+	}
+	if pkg == "" {
+		return
+	}
+	result := c.result(pkg, ev.Test)
+	switch ev.Action {
+	case "output", "build-output":
+		result.Output += ev.Output
+	case "pass", "fail", "skip":
+		result.Status = TestStatus(ev.Action)
+		result.Elapsed = time.Duration(ev.Elapsed * float64(time.Second))
+	case "build-fail":
+		result.Status = StatusFail
+	}
+}
+
+func (c *resultCollector) result(pkg, test string) *TestResult {
+	key := [2]string{pkg, test}
+	result := c.byKey[key]
+	if result == nil {
+		result = &TestResult{Package: pkg, Name: test, Attempts: 1}
+		c.byKey[key] = result
+		c.order = append(c.order, key)
+	}
+	return result
+}
+
+// results returns the collected results; tests without an outcome, such as tests running when a package panics or
+// times out, have failed
+func (c *resultCollector) results() []TestResult {
+	out := make([]TestResult, 0, len(c.order))
+	for _, key := range c.order {
+		result := *c.byKey[key]
+		if result.Status == "" {
+			result.Status = StatusFail
+		}
+		out = append(out, result)
+	}
+	return out
+}
+
+// Failures returns the failed tests without failed subtests, and the failed packages without any failed tests, such
+// as packages which do not build
+func Failures(results []TestResult) []TestResult {
+	var out []TestResult
+	for _, r := range results {
+		if r.Status != StatusFail {
+			continue
+		}
+		if slices.ContainsFunc(results, func(o TestResult) bool {
+			return o.Package == r.Package && o.Status == StatusFail && o.Name != r.Name &&
+				(r.Name == "" || strings.HasPrefix(o.Name, r.Name+"/"))
+		}) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// mergeRetry updates the results with the results of re-running failed tests, marking tests which failed and then
+// passed as flaky
+func mergeRetry(results, retry []TestResult) []TestResult {
+	for _, r := range retry {
+		idx := slices.IndexFunc(results, func(o TestResult) bool {
+			return o.Package == r.Package && o.Name == r.Name
+		})
+		if idx < 0 {
+			results = append(results, r)
+			continue
+		}
+		prev := results[idx]
+		r.Attempts = prev.Attempts + 1
+		r.Flaky = prev.Flaky
+		r.FailedOutput = prev.FailedOutput
+		if prev.Status == StatusFail {
+			r.FailedOutput = prev.Output
+			r.Flaky = r.Status == StatusPass
+		}
+		results[idx] = r
+	}
+	return results
+}
+
+// FailureSummary returns a summary of failed tests with their output
+func FailureSummary(results []TestResult) string {
+	sb := strings.Builder{}
+	for _, r := range Failures(results) {
+		if r.Name == "" {
+			sb.WriteString(fmt.Sprintf("--- FAIL: package %s\n", r.Package))
+		} else {
+			sb.WriteString(fmt.Sprintf("--- FAIL: %s (%s)\n", r.Name, r.Package))
+		}
+		for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+			sb.WriteString("    " + line + "\n")
+		}
+	}
+	return sb.String()
+}
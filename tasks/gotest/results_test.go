@@ -0,0 +1,170 @@
+package gotest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_ParseTestEvents(t *testing.T) {
+	fh := lang.Return(os.Open("testdata/go-test.json"))
+	defer lang.Close(fh, "testdata/go-test.json")
+	results := ParseTestEvents(fh)
+
+	statuses := map[string]TestStatus{}
+	for _, r := range results {
+		statuses[r.Package+" "+r.Name] = r.Status
+	}
+	require.Equal(t, map[string]TestStatus{
+		"example.com/ev/a TestPass":     StatusPass,
+		"example.com/ev/a TestFail":     StatusFail,
+		"example.com/ev/a TestFail/sub": StatusFail,
+		"example.com/ev/a TestFail/ok":  StatusPass,
+		"example.com/ev/a TestSkip":     StatusSkip,
+		"example.com/ev/a ":             StatusFail,
+		"example.com/ev/b ":             StatusFail,
+	}, statuses)
+
+	// only the failed subtest and the package which does not build are reported
+	failures := Failures(results)
+	require.Equal(t, 2, len(failures))
+	require.Equal(t, "TestFail/sub", failures[0].Name)
+	require.Contains(t, failures[0].Output, "a_test.go:10: failed")
+	require.Equal(t, "", failures[1].Name)
+	require.Contains(t, failures[1].Output, "cannot use \"x\"")
+
+	summary := FailureSummary(results)
+	require.Contains(t, summary, "--- FAIL: TestFail/sub (example.com/ev/a)\n")
+	require.Contains(t, summary, "    a_test.go:9: some output\n")
+	require.Contains(t, summary, "--- FAIL: package example.com/ev/b\n")
+
+	require.Equal(t, map[string][]string{"example.com/ev/a": {"TestFail"}}, retryableFailures(results))
+}
+
+func Test_WriteJUnit(t *testing.T) {
+	fh := lang.Return(os.Open("testdata/go-test.json"))
+	defer lang.Close(fh, "testdata/go-test.json")
+	results := ParseTestEvents(fh)
+
+	// a failed test with failed subtests is reported, but only counted once, as in the failure summary
+	path := filepath.Join(t.TempDir(), "reports", "junit.xml")
+	WriteJUnit(path, results)
+	require.Contains(t, file.Read(path), `<testsuites tests="6" failures="2" skipped="1"`)
+
+	results = mergeRetry(results, []TestResult{
+		{Package: "example.com/ev/a", Name: "TestFail", Status: StatusPass, Attempts: 1},
+		{Package: "example.com/ev/a", Name: "TestFail/sub", Status: StatusPass, Attempts: 1},
+		{Package: "example.com/ev/a", Status: StatusPass, Attempts: 1},
+	})
+
+	WriteJUnit(path, results)
+	contents := file.Read(path)
+
+	require.Contains(t, contents, `<testsuites tests="6" failures="1" skipped="1"`)
+	require.Contains(t, contents, `<testsuite name="example.com/ev/a" tests="5" failures="0" skipped="1"`)
+	require.Contains(t, contents, `<testcase classname="example.com/ev/a" name="TestFail/sub" time="0.000">`)
+	require.Contains(t, contents, `<flakyFailure message="passed after 2 attempts">`)
+	require.Contains(t, contents, `<testcase classname="example.com/ev/b" name="TestMain" time="0.000">`)
+	require.Contains(t, contents, `<failure message="Failed"><![CDATA[# example.com/ev/b`)
+}
+
+func Test_retryFailures(t *testing.T) {
+	dir := require.GitRepo(t)
+	counter := filepath.Join(t.TempDir(), "attempts")
+	require.GitCommit(t, dir, "add tests", map[string]string{
+		"go.mod": "module example.com/flaky\n\ngo 1.21\n",
+		"flaky_test.go": `package flaky
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFlaky(t *testing.T) {
+	if _, err := os.Stat("` + filepath.ToSlash(counter) + `"); err != nil {
+		_ = os.WriteFile("` + filepath.ToSlash(counter) + `", nil, 0o600)
+		t.Fatal("first attempt")
+	}
+}
+
+func TestBroken(t *testing.T) {
+	t.Fatal("always")
+}
+
+func TestStable(t *testing.T) {}
+`,
+	})
+
+	file.InDir(dir, func() {
+		cfg := defaultConfig()
+		args := []string{"test", "-json"}
+		results, err := runTests(cfg, append(args, "./..."))
+		require.Error(t, err)
+		require.Equal(t, 2, len(Failures(results)))
+
+		results = retryFailures(cfg, args, "", results)
+		failures := Failures(results)
+		require.Equal(t, 1, len(failures))
+		require.Equal(t, "TestBroken", failures[0].Name)
+		require.Equal(t, 2, failures[0].Attempts)
+
+		for _, r := range results {
+			require.Equal(t, r.Name == "TestFlaky", r.Flaky)
+			if r.Name == "TestStable" {
+				require.Equal(t, 1, r.Attempts)
+			}
+		}
+
+		cfg.JUnitFile = filepath.Join("reports", "junit.xml")
+		err = lang.Catch(func() { reportResults(cfg, results) })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "1 unit test failures")
+		require.True(t, strings.Contains(file.Read(cfg.JUnitFile), `name="TestFlaky"`))
+	})
+}
+
+func Test_retryFailuresNotRun(t *testing.T) {
+	dir := require.GitRepo(t)
+	counter := filepath.Join(t.TempDir(), "attempts")
+	require.GitCommit(t, dir, "add tests", map[string]string{
+		"go.mod": "module example.com/panics\n\ngo 1.21\n",
+		"panics_test.go": `package panics
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPanics(t *testing.T) {
+	if _, err := os.Stat("` + filepath.ToSlash(counter) + `"); err != nil {
+		_ = os.WriteFile("` + filepath.ToSlash(counter) + `", nil, 0o600)
+		panic("first attempt")
+	}
+}
+
+func TestNotStarted(t *testing.T) {}
+`,
+	})
+
+	file.InDir(dir, func() {
+		cfg := defaultConfig()
+		args := []string{"test", "-json"}
+		results, err := runTests(cfg, append(args, "./..."))
+		require.Error(t, err)
+		require.Equal(t, []string{"TestNotStarted"}, testsNotRun("example.com/panics", "", results))
+		require.Equal(t, 0, len(testsNotRun("example.com/panics", "^(TestPanics)$", results)))
+
+		// the package is re-run entirely, since TestNotStarted did not run
+		results = retryFailures(cfg, args, "", results)
+		require.Equal(t, 0, len(Failures(results)))
+		require.True(t, slices.ContainsFunc(results, func(r TestResult) bool {
+			return r.Name == "TestNotStarted" && r.Status == StatusPass
+		}))
+	})
+}
@@ -0,0 +1,230 @@
+package gotest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+)
+
+// Durations are the elapsed seconds of previous test runs, used to balance shards
+type Durations struct {
+	// Packages are the elapsed seconds by package import path
+	Packages map[string]float64 `json:"packages"`
+	// Tests are the elapsed seconds by top-level test name, summed across packages
+	Tests map[string]float64 `json:"tests"`
+}
+
+// ReadDurations reads the durations file, returning empty durations if the file does not exist
+func ReadDurations(path string) Durations {
+	out := Durations{Packages: map[string]float64{}, Tests: map[string]float64{}}
+	if path == "" || !file.Exists(path) {
+		return out
+	}
+	if err := json.Unmarshal([]byte(file.Read(path)), &out); err != nil {
+		log.Warn("unable to read test durations %s, shards will not be balanced: %v", path, err)
+	}
+	return out
+}
+
+// RecordDurations updates the durations file with the elapsed times of the results
+func RecordDurations(path string, results []TestResult) {
+	durations := ReadDurations(path)
+	tests := map[string]float64{}
+	for _, r := range results {
+		switch {
+		case r.Name == "":
+			durations.Packages[r.Package] = r.Elapsed.Seconds()
+		case !strings.Contains(r.Name, "/"):
+			tests[r.Name] += r.Elapsed.Seconds()
+		}
+	}
+	maps.Copy(durations.Tests, tests)
+	writeDurations(path, durations)
+}
+
+func writeDurations(path string, durations Durations) {
+	file.EnsureDir(filepath.Dir(path))
+	file.Write(path, string(lang.Return(json.MarshalIndent(durations, "", "  ")))+"\n")
+}
+
+// downloadDurations replaces the DurationsFile with the durations uploaded by all jobs of the latest successful run on
+// the base branch, see DurationsArtifact, and returns them. Every job must balance shards with the same durations, or
+// some tests run in no shard, so the job fails when the download fails, and empty durations are used when there is no
+// previous run, which balances shards by name
+func downloadDurations(cfg Config) Durations {
+	dir := lang.Return(os.MkdirTemp(config.TmpDir, "base-durations-"))
+	defer func() {
+		log.Error(os.RemoveAll(dir))
+	}()
+
+	durations := Durations{Packages: map[string]float64{}, Tests: map[string]float64{}}
+	branch, err := downloadBaseArtifacts(cfg.DurationsArtifact+"*", dir)
+	switch {
+	case errors.Is(err, github.ErrNoWorkflowRun):
+		log.Info("No previous test durations on %s, shards are balanced by name: %v", branch, err)
+	case err != nil:
+		panic(fmt.Errorf("unable to download test durations, which all shards must balance with: %w", err))
+	}
+	for _, f := range file.FindAll(filepath.Join(dir, "**", "*.json")) {
+		d := ReadDurations(f)
+		maps.Copy(durations.Packages, d.Packages)
+		maps.Copy(durations.Tests, d.Tests)
+	}
+	writeDurations(cfg.DurationsFile, durations)
+	log.Debug("test durations from %s: %s", branch, cfg.DurationsFile)
+	return durations
+}
+
+// uploadDurations uploads the DurationsFile as the DurationsArtifact with the matrix suffix, so the durations of each
+// job are uploaded; failures are logged but ignored
+func uploadDurations(cfg Config) {
+	err := lang.Catch(func() {
+		name := cfg.DurationsArtifact + github.MatrixSuffix
+		dir := lang.Return(os.MkdirTemp(config.TmpDir, "durations-"))
+		defer func() {
+			log.Error(os.RemoveAll(dir))
+		}()
+		// artifacts are extracted to the same directory, so each job's file is named after the artifact
+		durationsFile := filepath.Join(dir, name+".json")
+		file.Write(durationsFile, file.Read(cfg.DurationsFile))
+		lang.Return(github.NewClient().UploadArtifactDir(dir, github.UploadArtifactOption{
+			ArtifactName: name,
+			Files:        []string{durationsFile},
+		}))
+	})
+	if err != nil {
+		log.Debug("error uploading test durations: %v", err)
+	}
+}
+
+// shard returns the 1-based shard index and the total number of shards: the TEST_SHARDS environment variable
+// overrides the configured total, and the index is the configured index, the TEST_SHARD environment variable or the
+// ShardKey value of the GitHub matrix job, in that order
+func shard(cfg Config) (index, total int) {
+	total = cfg.Shards
+	if value := os.Getenv("TEST_SHARDS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			panic(fmt.Errorf("invalid TEST_SHARDS value %q: %w", value, err))
+		}
+		total = parsed
+	}
+	if total < 2 {
+		return 1, 1
+	}
+	index = cfg.Shard
+	if index == 0 {
+		value := os.Getenv("TEST_SHARD")
+		if value == "" {
+			value = fmt.Sprint(github.Matrix()[cfg.ShardKey])
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			panic(fmt.Errorf("unable to determine test shard of %d from TEST_SHARD or the %q matrix value, got: %q", total, cfg.ShardKey, value))
+		}
+		index = parsed
+	}
+	if index < 1 || index > total {
+		panic(fmt.Errorf("invalid test shard %d, expected 1 to %d", index, total))
+	}
+	return index, total
+}
+
+// shardPackages returns the packages of the shard
+func shardPackages(packages []string, durations map[string]float64, index, total int) []string {
+	return balance(packages, durations, total)[index-1]
+}
+
+// shardTests returns the packages with tests in the shard and a -run regex matching the tests of the shard; tests
+// with the same name in multiple packages are assigned to the same shard, so each test runs in exactly one shard
+func shardTests(packages []string, durations map[string]float64, index, total int) ([]string, string) {
+	testPackages := map[string][]string{}
+	for _, pkg := range packages {
+		for _, name := range listTests(pkg) {
+			testPackages[name] = append(testPackages[name], pkg)
+		}
+	}
+	tests := balance(slices.Collect(maps.Keys(testPackages)), durations, total)[index-1]
+
+	var shardPackages []string
+	for _, pkg := range packages {
+		if slices.ContainsFunc(tests, func(name string) bool { return slices.Contains(testPackages[name], pkg) }) {
+			shardPackages = append(shardPackages, pkg)
+		}
+	}
+	return shardPackages, "^(" + strings.Join(lang.Map(tests, regexp.QuoteMeta), "|") + ")$"
+}
+
+var testNamePattern = regexp.MustCompile(`^(Test|Example|Fuzz)\w*$`)
+
+// listTests returns the names of the top-level tests in the package
+func listTests(pkg string) []string {
+	var out []string
+	for _, line := range strings.Split(Run("go test -list .", run.Args(pkg), run.Quiet()), "\n") {
+		if line = strings.TrimSpace(line); testNamePattern.MatchString(line) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// balance deterministically splits the items into total groups: items are assigned from longest to shortest to the
+// group with the shortest total duration, items without a recorded duration use the average recorded duration
+func balance(items []string, durations map[string]float64, total int) [][]string {
+	known := 0.0
+	count := 0
+	for _, item := range items {
+		if d, ok := durations[item]; ok {
+			known += d
+			count++
+		}
+	}
+	average := 1.0
+	if count > 0 && known > 0 {
+		average = known / float64(count)
+	}
+	duration := func(item string) float64 {
+		if d, ok := durations[item]; ok {
+			return d
+		}
+		return average
+	}
+
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b string) int {
+		return cmp.Or(cmp.Compare(duration(b), duration(a)), strings.Compare(a, b))
+	})
+
+	groups := make([][]string, total)
+	totals := make([]float64, total)
+	for _, item := range sorted {
+		idx := 0
+		for i := range totals {
+			if totals[i] < totals[idx] {
+				idx = i
+			}
+		}
+		groups[idx] = append(groups[idx], item)
+		totals[idx] += duration(item)
+	}
+	for i := range groups {
+		slices.Sort(groups[i])
+	}
+	return groups
+}
@@ -0,0 +1,147 @@
+package gotest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_balance(t *testing.T) {
+	items := []string{"e", "d", "c", "b", "a"}
+
+	// without durations, items are distributed evenly in name order
+	require.Equal(t, [][]string{{"a", "d"}, {"b", "e"}, {"c"}}, balance(items, nil, 3))
+
+	// longest items first, unknown durations use the average of known durations
+	durations := map[string]float64{"a": 10, "b": 1, "c": 1, "d": 4}
+	require.Equal(t, [][]string{{"a"}, {"b", "c", "d", "e"}}, balance(items, durations, 2))
+
+	require.Equal(t, [][]string{{"a"}, {"d"}, {"e"}, {"b", "c"}}, balance(items, durations, 4))
+}
+
+func Test_shard(t *testing.T) {
+	t.Setenv("TEST_SHARDS", "")
+	t.Setenv("TEST_SHARD", "")
+	t.Setenv("MATRIX_JSON", "")
+
+	cfg := defaultConfig()
+	index, total := shard(cfg)
+	require.Equal(t, 1, index)
+	require.Equal(t, 1, total)
+
+	Shards(3)(&cfg)
+	require.Error(t, lang.Catch(func() { shard(cfg) }))
+
+	t.Setenv("MATRIX_JSON", `{"os":"linux","shard":2}`)
+	index, total = shard(cfg)
+	require.Equal(t, 2, index)
+	require.Equal(t, 3, total)
+
+	t.Setenv("TEST_SHARD", "3")
+	index, _ = shard(cfg)
+	require.Equal(t, 3, index)
+
+	Shard(1, 3)(&cfg)
+	index, _ = shard(cfg)
+	require.Equal(t, 1, index)
+
+	t.Setenv("TEST_SHARDS", "1")
+	_, total = shard(cfg)
+	require.Equal(t, 1, total)
+
+	t.Setenv("TEST_SHARDS", "")
+	t.Setenv("TEST_SHARD", "4")
+	Shard(0, 3)(&cfg)
+	require.Error(t, lang.Catch(func() { shard(cfg) }))
+}
+
+func Test_shardTests(t *testing.T) {
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "add tests", map[string]string{
+		"go.mod":      "module example.com/sharded\n\ngo 1.21\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestSlow(t *testing.T) {}\n\nfunc TestShared(t *testing.T) {}\n\nfunc BenchmarkA(b *testing.B) {}\n",
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestShared(t *testing.T) {}\n\nfunc TestOther(t *testing.T) {}\n",
+		"c/c.go":      "package c\n",
+		"d/d_test.go": "package d\n\nimport \"testing\"\n\nfunc TestFast(t *testing.T) {}\n",
+	})
+
+	file.InDir(dir, func() {
+		packages := []string{"example.com/sharded/a", "example.com/sharded/b", "example.com/sharded/c", "example.com/sharded/d"}
+		durations := map[string]float64{"TestSlow": 10, "TestShared": 2, "TestOther": 1, "TestFast": 1}
+
+		shardPackages, pattern := shardTests(packages, durations, 1, 2)
+		require.Equal(t, []string{"example.com/sharded/a"}, shardPackages)
+		require.Equal(t, "^(TestSlow)$", pattern)
+
+		shardPackages, pattern = shardTests(packages, durations, 2, 2)
+		require.Equal(t, []string{"example.com/sharded/a", "example.com/sharded/b", "example.com/sharded/d"}, shardPackages)
+		require.Equal(t, "^(TestFast|TestOther|TestShared)$", pattern)
+	})
+}
+
+func Test_RecordDurations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "durations.json")
+	require.Equal(t, 0, len(ReadDurations(path).Packages))
+
+	RecordDurations(path, []TestResult{
+		{Package: "example.com/a", Elapsed: 3 * time.Second},
+		{Package: "example.com/a", Name: "TestA", Elapsed: 2 * time.Second},
+		{Package: "example.com/a", Name: "TestA/sub", Elapsed: time.Second},
+		{Package: "example.com/b", Name: "TestA", Elapsed: time.Second},
+	})
+	RecordDurations(path, []TestResult{
+		{Package: "example.com/b", Elapsed: 1500 * time.Millisecond},
+	})
+
+	durations := ReadDurations(path)
+	require.Equal(t, map[string]float64{"example.com/a": 3, "example.com/b": 1.5}, durations.Packages)
+	require.Equal(t, map[string]float64{"TestA": 3}, durations.Tests)
+}
+
+func Test_downloadDurations(t *testing.T) {
+	require.SetAndRestore(t, &downloadBaseArtifacts, func(artifactNameGlob, dir string) (string, error) {
+		require.Equal(t, "test-durations*", artifactNameGlob)
+		file.Write(filepath.Join(dir, "test-durations_1.json"), `{"packages":{"example.com/a":2},"tests":{"TestA":2}}`)
+		file.Write(filepath.Join(dir, "test-durations_2.json"), `{"packages":{"example.com/b":4},"tests":{"TestB":4}}`)
+		return "main", nil
+	})
+
+	cfg := defaultConfig()
+	DurationsFile(filepath.Join(t.TempDir(), "durations", "durations.json"))(&cfg)
+	DurationsArtifact("test-durations")(&cfg)
+	RecordDurations(cfg.DurationsFile, []TestResult{
+		{Package: "example.com/a", Elapsed: 3 * time.Second},
+		{Package: "example.com/c", Elapsed: time.Second},
+	})
+
+	// local durations are replaced, since they may differ between jobs
+	expected := Durations{
+		Packages: map[string]float64{"example.com/a": 2, "example.com/b": 4},
+		Tests:    map[string]float64{"TestA": 2, "TestB": 4},
+	}
+	require.Equal(t, expected, downloadDurations(cfg))
+	require.Equal(t, expected, ReadDurations(cfg.DurationsFile))
+
+	// without a previous run, shards are balanced by name
+	require.SetAndRestore(t, &downloadBaseArtifacts, func(_, _ string) (string, error) {
+		return "main", fmt.Errorf("%w for owner/repo workflow: ci", github.ErrNoWorkflowRun)
+	})
+	durations := downloadDurations(cfg)
+	require.Equal(t, 0, len(durations.Packages)+len(durations.Tests))
+	require.Equal(t, 0, len(ReadDurations(cfg.DurationsFile).Packages))
+
+	// other failures fail the job, rather than balancing shards differently than other jobs
+	require.SetAndRestore(t, &downloadBaseArtifacts, func(_, _ string) (string, error) {
+		return "main", errors.New("server error")
+	})
+	require.Error(t, lang.Catch(func() {
+		downloadDurations(cfg)
+	}))
+}
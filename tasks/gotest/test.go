@@ -2,9 +2,11 @@ package gotest
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,10 +15,10 @@ import (
 	. "github.com/anchore/go-make"
 	"github.com/anchore/go-make/config"
 	"github.com/anchore/go-make/file"
-	"github.com/anchore/go-make/github"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
 	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/stream"
 )
 
 func Tasks(options ...Option) Task {
@@ -29,16 +31,34 @@ func Tasks(options ...Option) Task {
 		Name:        cfg.Name,
 		Description: fmt.Sprintf("run %s tests", cfg.Name),
 		RunsOn:      Deps("test"),
+		Paths:       cfg.Paths,
 		Run: func() {
 			start := time.Now()
-			args := Deps("test")
-			if cfg.Verbose {
-				args = append(args, "-v")
+			args := append(Deps("test"), "-json")
+			if cfg.Race {
+				args = append(args, "-race")
+			}
+			if cfg.Coverage {
+				args = append(args, "-tags=coverage")
 			}
-			args = append(args, selectPackages(cfg.IncludeGlob, cfg.ExcludeGlob)...)
+			// retries do not write coverage profiles, which would overwrite the complete profile
+			retryArgs := slices.Clone(args)
 
+			packages := selectPackages(cfg.IncludeGlob, cfg.ExcludeGlob)
+			if cfg.AffectedOnly && config.ChangedOnly {
+				packages = affectedPackages(packages)
+				if len(packages) == 0 {
+					Log("No packages affected by changes, skipping %s tests", cfg.Name)
+					return
+				}
+			}
 			coverageFile := cfg.CoverageFile
 			if cfg.Coverage {
+				if coverageFile == "" && cfg.CoverageDir != "" {
+					// profiles are kept in the CoverageDir to be merged by the CoverageTask
+					file.EnsureDir(cfg.CoverageDir)
+					coverageFile = lang.Return(filepath.Abs(filepath.Join(cfg.CoverageDir, cfg.Name+".out")))
+				}
 				if coverageFile == "" {
 					coverageDir, err := os.MkdirTemp(config.TmpDir, "cover-dir-")
 					if err == nil {
@@ -52,51 +72,70 @@ func Tasks(options ...Option) Task {
 					}
 				}
 				args = append(args, "-coverprofile", coverageFile)
-				args = append(args, "-covermode=atomic", "-coverpkg=./...", "-tags=coverage")
+				args = append(args, "-covermode=atomic", "-coverpkg=./...")
 			}
-
-			if cfg.Race {
-				args = append(args, "-race")
+			index, shards := shard(cfg)
+			shardPattern := ""
+			if shards > 1 {
+				packages = strings.Fields(Run("go list", run.Args(packages...), run.Quiet()))
+				var durations Durations
+				if cfg.DurationsArtifact != "" && cfg.DurationsFile != "" && config.CI {
+					durations = downloadDurations(cfg)
+				} else {
+					durations = ReadDurations(cfg.DurationsFile)
+				}
+				if cfg.ShardTests {
+					packages, shardPattern = shardTests(packages, durations.Tests, index, shards)
+					args = append(args, "-run", shardPattern)
+				} else {
+					packages = shardPackages(packages, durations.Packages, index, shards)
+				}
+				if len(packages) == 0 {
+					Log("No %s tests in shard %d of %d", cfg.Name, index, shards)
+					return
+				}
+				Log("Running %s test shard %d of %d", cfg.Name, index, shards)
 			}
+			args = append(args, packages...)
 
-			Run("go", run.Args(args...), run.Stdout(os.Stderr), run.Env("GODEBUG", "dontfreezetheworld=1"))
+			results, err := runTests(cfg, args)
+			if err != nil && len(Failures(results)) == 0 {
+				panic(err)
+			}
+			for attempt := 1; attempt <= cfg.Retries && len(retryableFailures(results)) > 0; attempt++ {
+				results = retryFailures(cfg, retryArgs, shardPattern, results)
+			}
 
 			Log("Done running %s tests in %v", cfg.Name, time.Since(start))
-
-			if coverageFile != "" && cfg.CoverageFile == "" {
-				report := Run("go tool cover", run.Args("-func", coverageFile), run.Quiet())
-				if cfg.Verbose {
-					Log(" -------------- Coverage Report -------------- ")
-					Log(report)
-				} else {
-					coverage := regexp.MustCompile(`total:[^\n%]+?(\d+\.\d+)%`).FindStringSubmatch(report)
-					if len(coverage) > 1 {
-						Log("Coverage: %s%%", coverage[1])
-					} else {
-						Log(" -------------- Coverage Report -------------- ")
-						log.Error(fmt.Errorf("unable to find coverage percentage in report"))
-						Log(report)
-					}
+			if cfg.DurationsFile != "" {
+				recorded := results
+				if shardPattern != "" {
+					// package durations only include the tests of the shard
+					recorded = slices.DeleteFunc(slices.Clone(results), func(r TestResult) bool { return r.Name == "" })
+				}
+				RecordDurations(cfg.DurationsFile, recorded)
+				if cfg.DurationsArtifact != "" && config.CI {
+					uploadDurations(cfg)
 				}
 			}
+			reportResults(cfg, results)
 
-			if coverageFile != "" && config.OS == "linux" {
-				err := lang.Catch(func() {
-					dir := filepath.Dir(coverageFile)
-					lang.Return(github.NewClient().UploadArtifactDir(dir, github.UploadArtifactOption{
-						ArtifactName: "code-coverage",
-						Overwrite:    false, // we only need one, failures are logged but ignored
-						Files:        []string{coverageFile},
-					}))
-				})
-				if err != nil {
-					log.Debug("error uploading coverage file: %v", err)
+			if coverageFile != "" {
+				if shards > 1 {
+					// the profile only covers the tests of this shard, see Shards
+					log.Info("Coverage of shard %d of %d is partial, skipping coverage minimums and comparison", index, shards)
+					cfg.MinCoverage, cfg.MinPackageCoverage, cfg.CompareCoverage = 0, 0, false
 				}
+				ReportCoverage(cfg, coverageFile)
 			}
 		},
 	}
 }
 
+// DefaultPaths are the files which affect tests, the tests are skipped when config.ChangedOnly is enabled and no
+// changed file matches
+var DefaultPaths = []string{"**/*.go", "**/go.mod", "**/go.sum", "**/testdata/**"}
+
 type Config struct {
 	Name         string
 	IncludeGlob  string
@@ -105,6 +144,46 @@ type Config struct {
 	Coverage     bool
 	CoverageFile string
 	Race         bool
+	AffectedOnly bool
+	// Paths skip the tests when config.ChangedOnly is enabled and no changed file matches, see Task.Paths
+	Paths []string
+	// JUnitFile is the path to write a JUnit XML report of the test results
+	JUnitFile string
+	// Retries is the number of times failed tests are re-run; tests passing on a retry are reported as flaky
+	Retries int
+	// Shards is the number of shards to split tests into, e.g. across CI matrix jobs
+	Shards int
+	// Shard is the 1-based shard to run, if not set: the TEST_SHARD environment variable or the ShardKey matrix value
+	Shard int
+	// ShardKey is the GitHub matrix value with the shard to run
+	ShardKey string
+	// ShardTests splits individual tests into shards using -run patterns, instead of packages
+	ShardTests bool
+	// DurationsFile records the durations of test runs, which balance shards in subsequent runs
+	DurationsFile string
+	// DurationsArtifact is the name of the GitHub Actions artifacts the DurationsFile of each job is uploaded as
+	DurationsArtifact string
+	// CoverageDir is the directory to write coverage profiles named after the task, to be merged by the CoverageTask
+	CoverageDir string
+	// MergeCoverage are the names of the test tasks with profiles merged by the CoverageTask
+	MergeCoverage []string
+	// MinCoverage is the minimum total coverage percentage
+	MinCoverage float64
+	// MinPackageCoverage is the minimum coverage percentage of each package
+	MinPackageCoverage float64
+	// CoverageHTML is the path to write an HTML coverage report
+	CoverageHTML string
+	// CoverageCobertura is the path to write a Cobertura XML coverage report
+	CoverageCobertura string
+	// CoverageArtifact is the name of the GitHub Actions artifact the coverage profile is uploaded as
+	CoverageArtifact string
+	// CoverageArtifactPerJob appends the GitHub matrix suffix to the CoverageArtifact, uploading a profile per job
+	CoverageArtifactPerJob bool
+	// CompareCoverage compares coverage to the CoverageArtifact of the latest successful run on the base branch
+	CompareCoverage bool
+	// MaxCoverageDecrease is the maximum decrease in total coverage percentage compared to the base branch, negative
+	// values allow any decrease
+	MaxCoverageDecrease float64
 }
 
 func defaultConfig() Config {
@@ -113,6 +192,11 @@ func defaultConfig() Config {
 		IncludeGlob: "./...",
 		Coverage:    true,
 		Race:        config.CI && !config.Windows,
+		ShardKey:    "shard",
+		Paths:       DefaultPaths,
+
+		CoverageArtifact:    "code-coverage",
+		MaxCoverageDecrease: -1,
 	}
 }
 
@@ -142,6 +226,240 @@ func Verbose() Option {
 	}
 }
 
+// JUnit writes a JUnit XML report of the test results to the path, e.g. for CI test reporting
+func JUnit(path string) Option {
+	return func(c *Config) {
+		c.JUnitFile = path
+	}
+}
+
+// Retries re-runs failed tests up to the number of times, reporting tests which pass on a retry as flaky
+func Retries(retries int) Option {
+	return func(c *Config) {
+		c.Retries = retries
+	}
+}
+
+// Shards splits packages into the number of shards, running the shard from the TEST_SHARD environment variable or the
+// "shard" value of the GitHub matrix job, e.g. with a matrix of shard: [1, 2, 3]. The coverage profile of a shard is
+// partial, so MinCoverage, MinPackageCoverage and CompareCoverage are skipped; to check coverage, upload each profile
+// with CoverageArtifactPerJob, download the artifacts to the CoverageDir and merge the profiles with the CoverageTask
+func Shards(total int) Option {
+	return func(c *Config) {
+		c.Shards = total
+	}
+}
+
+// Shard runs the 1-based shard of the total number of shards
+func Shard(index, total int) Option {
+	return func(c *Config) {
+		c.Shard = index
+		c.Shards = total
+	}
+}
+
+// ShardKey sets the GitHub matrix value with the shard to run
+func ShardKey(key string) Option {
+	return func(c *Config) {
+		c.ShardKey = key
+	}
+}
+
+// ShardTests splits individual tests into shards instead of packages, for modules with few large packages
+func ShardTests() Option {
+	return func(c *Config) {
+		c.ShardTests = true
+	}
+}
+
+// DurationsFile records test durations to the file, used to balance shards when present from previous runs. Each job
+// only records the durations of its shard, so the file must be kept between runs and shared by the jobs, e.g. with
+// DurationsArtifact; package durations are not recorded with ShardTests, since only the tests of the shard are run
+func DurationsFile(path string) Option {
+	return func(c *Config) {
+		c.DurationsFile = path
+	}
+}
+
+// DurationsArtifact shares the DurationsFile between the jobs of GitHub Actions runs: before sharding, the DurationsFile
+// is replaced with the durations uploaded by all jobs of the latest successful run on the base branch, so all jobs
+// balance shards the same way, and after the tests run, the DurationsFile is uploaded as the artifact name with the
+// matrix suffix. A job fails when the durations cannot be downloaded, and shards are balanced by name when there is
+// no previous run
+func DurationsArtifact(name string) Option {
+	return func(c *Config) {
+		c.DurationsArtifact = name
+	}
+}
+
+// CoverageDir writes the coverage profile to the directory, named after the task, to be merged by the CoverageTask
+func CoverageDir(dir string) Option {
+	return func(c *Config) {
+		c.CoverageDir = dir
+	}
+}
+
+// MergeCoverage sets the names of the test tasks with coverage profiles merged by the CoverageTask
+func MergeCoverage(names ...string) Option {
+	return func(c *Config) {
+		c.MergeCoverage = names
+	}
+}
+
+// MinCoverage fails if the total coverage percentage is below the minimum
+func MinCoverage(percent float64) Option {
+	return func(c *Config) {
+		c.MinCoverage = percent
+	}
+}
+
+// MinPackageCoverage fails if the coverage percentage of any package is below the minimum
+func MinPackageCoverage(percent float64) Option {
+	return func(c *Config) {
+		c.MinPackageCoverage = percent
+	}
+}
+
+func CoverageHTML(path string) Option {
+	return func(c *Config) {
+		c.CoverageHTML = path
+	}
+}
+
+func CoverageCobertura(path string) Option {
+	return func(c *Config) {
+		c.CoverageCobertura = path
+	}
+}
+
+func CoverageArtifact(name string) Option {
+	return func(c *Config) {
+		c.CoverageArtifact = name
+	}
+}
+
+// CoverageArtifactPerJob appends the GitHub matrix suffix to the coverage artifact name, so each matrix job uploads
+// its profile rather than only the first job, e.g. code-coverage_linux_2 for a matrix of os and shard
+func CoverageArtifactPerJob() Option {
+	return func(c *Config) {
+		c.CoverageArtifactPerJob = true
+	}
+}
+
+// CompareCoverage compares coverage to the latest successful run on the base branch, failing if total coverage
+// decreased more than maxDecrease percent; a negative maxDecrease only reports the change
+func CompareCoverage(maxDecrease float64) Option {
+	return func(c *Config) {
+		c.CompareCoverage = true
+		c.MaxCoverageDecrease = maxDecrease
+	}
+}
+
+// AffectedOnly restricts tests to packages affected by the changed files when config.ChangedOnly is enabled,
+// including packages depending on changed packages
+func AffectedOnly() Option {
+	return func(c *Config) {
+		c.AffectedOnly = true
+	}
+}
+
+// Paths sets the globs of files which affect the tests, see Task.Paths; no globs never skips the tests
+func Paths(globs ...string) Option {
+	return func(c *Config) {
+		c.Paths = globs
+	}
+}
+
+// runTests runs go test with the -json arg, printing test output and returning the results; all output is printed
+// when Verbose, otherwise only package output such as the package status lines
+func runTests(cfg Config, args []string) ([]TestResult, error) {
+	collector := newResultCollector(func(ev TestEvent) {
+		if (ev.Action == "output" && (cfg.Verbose || ev.Test == "")) || ev.Action == "build-output" {
+			_, _ = os.Stderr.WriteString(ev.Output)
+		}
+	})
+	collector.onOther = func(line string) {
+		_, _ = os.Stderr.WriteString(line + "\n")
+	}
+	lines := stream.Lines(collector.line)
+	err := lang.Catch(func() {
+		Run("go", run.Args(args...), run.Stdout(lines), run.Env("GODEBUG", "dontfreezetheworld=1"))
+	})
+	log.Error(lines.Close())
+	return collector.results(), err
+}
+
+// retryableFailures returns the top-level names of failed tests by package; packages which fail without a failed
+// test, such as build failures, are not retried
+func retryableFailures(results []TestResult) map[string][]string {
+	out := map[string][]string{}
+	for _, r := range Failures(results) {
+		if r.Name != "" && !slices.Contains(out[r.Package], r.TopLevel()) {
+			out[r.Package] = append(out[r.Package], r.TopLevel())
+		}
+	}
+	return out
+}
+
+// retryFailures re-runs the failed tests of each package, merging the results; all tests matching the runPattern are
+// re-run in packages which did not run all tests, such as when a test panics
+func retryFailures(cfg Config, args []string, runPattern string, results []TestResult) []TestResult {
+	failures := retryableFailures(results)
+	for _, pkg := range slices.Sorted(maps.Keys(failures)) {
+		retryArgs := append(slices.Clone(args), "-count=1")
+		if notRun := testsNotRun(pkg, runPattern, results); len(notRun) > 0 {
+			Log("Retrying all tests in %s, tests did not run: %s", pkg, strings.Join(notRun, ", "))
+			if runPattern != "" {
+				retryArgs = append(retryArgs, "-run", runPattern)
+			}
+		} else {
+			names := lang.Map(failures[pkg], regexp.QuoteMeta)
+			Log("Retrying failed tests in %s: %s", pkg, strings.Join(failures[pkg], ", "))
+			retryArgs = append(retryArgs, "-run", "^("+strings.Join(names, "|")+")$")
+		}
+		retry, err := runTests(cfg, append(retryArgs, pkg))
+		if err != nil && len(retry) == 0 {
+			panic(err)
+		}
+		results = mergeRetry(results, retry)
+	}
+	return results
+}
+
+// testsNotRun returns the top-level tests of the package matching the runPattern without a result, such as tests
+// not started because an earlier test panicked
+func testsNotRun(pkg, runPattern string, results []TestResult) []string {
+	var out []string
+	for _, name := range listTests(pkg) {
+		if runPattern != "" && !regexp.MustCompile(runPattern).MatchString(name) {
+			continue
+		}
+		if !slices.ContainsFunc(results, func(r TestResult) bool { return r.Package == pkg && r.Name == name }) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// reportResults writes the JUnit report, if configured, and fails with a summary of any failed tests
+func reportResults(cfg Config, results []TestResult) {
+	if cfg.JUnitFile != "" {
+		WriteJUnit(cfg.JUnitFile, results)
+		log.Debug("wrote JUnit report: %s", cfg.JUnitFile)
+	}
+	for _, r := range results {
+		if r.Flaky && r.Name != "" {
+			log.Warn("flaky test %s (%s) passed after %d attempts", r.Name, r.Package, r.Attempts)
+		}
+	}
+	failures := Failures(results)
+	if len(failures) == 0 {
+		return
+	}
+	_, _ = os.Stderr.WriteString("\n" + FailureSummary(results))
+	panic(fmt.Errorf("%d %s test failures", len(failures), cfg.Name))
+}
+
 func selectPackages(include, exclude string) []string {
 	if exclude == "" {
 		return []string{include}
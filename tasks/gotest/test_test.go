@@ -14,6 +14,7 @@ func Test_Task(t *testing.T) {
 
 	require.Equal(t, "secondary", task.Name)
 	require.Contains(t, task.Description, "secondary")
+	require.Equal(t, gotest.DefaultPaths, task.Paths)
 
 	cfg := gotest.Config{}
 
@@ -25,4 +26,36 @@ func Test_Task(t *testing.T) {
 
 	gotest.ExcludeGlob("**/*skip*")(&cfg)
 	require.Equal(t, "**/*skip*", cfg.ExcludeGlob)
+
+	gotest.AffectedOnly()(&cfg)
+	require.Equal(t, true, cfg.AffectedOnly)
+
+	gotest.Paths("**/*.go", "fixtures/**")(&cfg)
+	require.Equal(t, []string{"**/*.go", "fixtures/**"}, cfg.Paths)
+
+	gotest.JUnit("reports/junit.xml")(&cfg)
+	require.Equal(t, "reports/junit.xml", cfg.JUnitFile)
+
+	gotest.Retries(2)(&cfg)
+	require.Equal(t, 2, cfg.Retries)
+
+	gotest.Shards(4)(&cfg)
+	gotest.ShardTests()(&cfg)
+	gotest.ShardKey("part")(&cfg)
+	gotest.DurationsFile(".tmp/test-durations.json")(&cfg)
+	require.Equal(t, 4, cfg.Shards)
+	require.Equal(t, true, cfg.ShardTests)
+	require.Equal(t, "part", cfg.ShardKey)
+	require.Equal(t, ".tmp/test-durations.json", cfg.DurationsFile)
+}
+
+func Test_CoverageTask(t *testing.T) {
+	task := gotest.CoverageTask(
+		gotest.CoverageDir(".tmp/coverage"),
+		gotest.MergeCoverage("unit", "integration"),
+	)
+
+	require.Equal(t, "coverage", task.Name)
+	require.Equal(t, []string{"unit", "integration"}, task.Dependencies)
+	require.Contains(t, task.Description, "unit, integration")
 }
@@ -0,0 +1,87 @@
+package govuln
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+)
+
+// Allowlist is the set of vulnerabilities allowed to be found, read from a YAML file such as:
+//
+//	allow:
+//	  - id: GO-2024-1234        # the vulnerability ID or any alias, e.g. CVE-2024-1234
+//	    package: example.com/a  # optional, only allows the vulnerability in this package
+//	    reason: not reachable in our usage
+//	    expires: 2025-06-30     # optional, the entry no longer applies after this date
+type Allowlist struct {
+	Allow []AllowEntry `yaml:"allow"`
+}
+
+type AllowEntry struct {
+	ID      string `yaml:"id"`
+	Package string `yaml:"package"`
+	Reason  string `yaml:"reason"`
+	Expires string `yaml:"expires"`
+}
+
+// now is used to check expiry
+var now = time.Now
+
+// ReadAllowlist reads the allowlist file, returning an empty allowlist if the file does not exist
+func ReadAllowlist(path string) Allowlist {
+	var out Allowlist
+	if path == "" || !file.Exists(path) {
+		return out
+	}
+	contents := lang.Return(os.ReadFile(path))
+	if err := yaml.Unmarshal(contents, &out); err != nil {
+		panic(fmt.Errorf("unable to read allowlist %s: %w", path, err))
+	}
+	for _, entry := range out.Allow {
+		if entry.ID == "" {
+			panic(fmt.Errorf("allowlist %s has an entry without an id", path))
+		}
+		if _, err := entry.expiry(); err != nil {
+			panic(fmt.Errorf("allowlist %s entry %s has an invalid expiry, expected YYYY-MM-DD: %w", path, entry.ID, err))
+		}
+	}
+	return out
+}
+
+// Allows indicates an entry that has not expired matches the finding
+func (a Allowlist) Allows(f Finding) bool {
+	for _, entry := range a.Allow {
+		if !f.Matches(entry.ID) || (entry.Package != "" && entry.Package != f.Package) {
+			continue
+		}
+		if entry.expired() {
+			log.Warn("allowlist entry for %s expired on %s: %s", entry.ID, entry.Expires, entry.Reason)
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (e AllowEntry) expiry() (time.Time, error) {
+	if strings.TrimSpace(e.Expires) == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, strings.TrimSpace(e.Expires))
+}
+
+// expired indicates the entry expired before today; entries apply through the end of the expiry date
+func (e AllowEntry) expired() bool {
+	expires, err := e.expiry()
+	if err != nil || expires.IsZero() {
+		return false
+	}
+	return now().After(expires.AddDate(0, 0, 1))
+}
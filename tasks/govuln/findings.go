@@ -0,0 +1,176 @@
+package govuln
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/anchore/go-make/lang"
+)
+
+// Severity is the severity of a vulnerability, ordered from least to most severe
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityNegligible
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"unknown", "negligible", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// ParseSeverity returns the severity for a case-insensitive name, e.g. High
+func ParseSeverity(name string) (Severity, error) {
+	idx := slices.Index(severityNames, strings.ToLower(strings.TrimSpace(name)))
+	if idx < 0 {
+		return SeverityUnknown, fmt.Errorf("invalid severity %q, expected one of: %s", name, strings.Join(severityNames, ", "))
+	}
+	return Severity(idx), nil
+}
+
+// Finding is a vulnerability found in a package
+type Finding struct {
+	// ID is the vulnerability identifier, e.g. GO-2024-1234 or CVE-2024-1234
+	ID string
+	// Aliases are other identifiers of the vulnerability, e.g. CVE and GHSA identifiers
+	Aliases      []string
+	Package      string
+	Version      string
+	FixedVersion string
+	Severity     Severity
+	Summary      string
+	// Scanner is the scanner reporting the finding
+	Scanner Scanner
+	// Allowed indicates the finding is allowed by the allowlist
+	Allowed bool
+}
+
+// Matches indicates the finding has the id, or an alias of it
+func (f Finding) Matches(id string) bool {
+	return strings.EqualFold(f.ID, id) || slices.ContainsFunc(f.Aliases, func(alias string) bool {
+		return strings.EqualFold(alias, id)
+	})
+}
+
+// ParseGovulncheck returns the findings from `govulncheck -json` output, only including vulnerabilities in called
+// functions, consistent with the default govulncheck text output
+func ParseGovulncheck(output string) []Finding {
+	type osv struct {
+		ID      string   `json:"id"`
+		Aliases []string `json:"aliases"`
+		Summary string   `json:"summary"`
+	}
+	type frame struct {
+		Module   string `json:"module"`
+		Version  string `json:"version"`
+		Package  string `json:"package"`
+		Function string `json:"function"`
+	}
+	type message struct {
+		OSV     *osv `json:"osv"`
+		Finding *struct {
+			OSV          string  `json:"osv"`
+			FixedVersion string  `json:"fixed_version"`
+			Trace        []frame `json:"trace"`
+		} `json:"finding"`
+	}
+
+	entries := map[string]osv{}
+	var out []Finding
+	dec := json.NewDecoder(strings.NewReader(output))
+	for {
+		var msg message
+		err := dec.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		lang.Throw(err)
+		if msg.OSV != nil {
+			entries[msg.OSV.ID] = *msg.OSV
+		}
+		if msg.Finding == nil || len(msg.Finding.Trace) == 0 || msg.Finding.Trace[0].Function == "" {
+			continue
+		}
+		vulnerable := msg.Finding.Trace[0]
+		f := Finding{
+			ID:           msg.Finding.OSV,
+			Package:      vulnerable.Module,
+			Version:      vulnerable.Version,
+			FixedVersion: msg.Finding.FixedVersion,
+			Scanner:      Govulncheck,
+		}
+		// a finding is reported for each call path
+		if !slices.ContainsFunc(out, func(o Finding) bool { return o.ID == f.ID && o.Package == f.Package }) {
+			out = append(out, f)
+		}
+	}
+
+	// osv entries are reported before findings, but may not be for all findings
+	for i := range out {
+		if entry, ok := entries[out[i].ID]; ok {
+			out[i].Aliases = entry.Aliases
+			out[i].Summary = entry.Summary
+		}
+	}
+	return out
+}
+
+// ParseGrype returns the findings from `grype -o json` output
+func ParseGrype(output string) []Finding {
+	var doc struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID          string `json:"id"`
+				Severity    string `json:"severity"`
+				Description string `json:"description"`
+				Fix         struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			RelatedVulnerabilities []struct {
+				ID string `json:"id"`
+			} `json:"relatedVulnerabilities"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	lang.Throw(json.Unmarshal([]byte(output), &doc))
+
+	var out []Finding
+	for _, m := range doc.Matches {
+		severity, _ := ParseSeverity(m.Vulnerability.Severity)
+		f := Finding{
+			ID:       m.Vulnerability.ID,
+			Package:  m.Artifact.Name,
+			Version:  m.Artifact.Version,
+			Severity: severity,
+			Summary:  m.Vulnerability.Description,
+			Scanner:  Grype,
+		}
+		if len(m.Vulnerability.Fix.Versions) > 0 {
+			f.FixedVersion = m.Vulnerability.Fix.Versions[0]
+		}
+		for _, related := range m.RelatedVulnerabilities {
+			if related.ID != f.ID && !slices.Contains(f.Aliases, related.ID) {
+				f.Aliases = append(f.Aliases, related.ID)
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,219 @@
+package govuln
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+)
+
+// Scanner is a vulnerability scanner
+type Scanner string
+
+const (
+	// Govulncheck reports vulnerabilities in code called by the module, see: https://go.dev/doc/security/vuln
+	Govulncheck Scanner = "govulncheck"
+	// Grype reports vulnerabilities in all dependencies of the module and any snapshot binaries
+	Grype Scanner = "grype"
+)
+
+// snapshotDir is the goreleaser snapshot output directory, scanned by grype when present
+const snapshotDir = "snapshot"
+
+func Tasks(options ...Option) Task {
+	cfg := defaultConfig()
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return Task{
+		Name:        cfg.Name,
+		Description: "scan for vulnerabilities",
+		RunsOn:      lang.List("static-analysis"),
+		Run: func() {
+			if failOn := os.Getenv("FAIL_ON_SEVERITY"); failOn != "" {
+				cfg.FailOn = lang.Return(ParseSeverity(failOn))
+			}
+			Scan(cfg)
+		},
+	}
+}
+
+type Config struct {
+	// Name is the task name
+	Name string
+	// Scanners are the scanners to run
+	Scanners []Scanner
+	// GrypeTargets are the grype sources to scan, defaults to the module directory and the snapshot directory if present
+	GrypeTargets []string
+	// Allowlist is the YAML file of allowed vulnerabilities, see Allowlist
+	Allowlist string
+	// FailOn is the minimum severity of vulnerabilities not allowed to fail the scan
+	FailOn Severity
+	// UnknownSeverity is used for findings without a severity, such as all govulncheck findings, because the Go
+	// vulnerability database does not include severities
+	UnknownSeverity Severity
+}
+
+func defaultConfig() Config {
+	return Config{
+		Name:            "vulnerabilities",
+		Scanners:        []Scanner{Govulncheck},
+		Allowlist:       ".vulnerability-allowlist.yaml",
+		FailOn:          SeverityHigh,
+		UnknownSeverity: SeverityHigh,
+	}
+}
+
+type Option func(*Config)
+
+func Name(name string) Option {
+	return func(c *Config) {
+		c.Name = name
+	}
+}
+
+// Scanners sets the scanners to run, e.g. Scanners(govuln.Govulncheck, govuln.Grype)
+func Scanners(scanners ...Scanner) Option {
+	return func(c *Config) {
+		c.Scanners = scanners
+	}
+}
+
+// GrypeTargets sets the grype sources to scan, e.g. GrypeTargets("dir:.", "docker:my-image:latest")
+func GrypeTargets(targets ...string) Option {
+	return func(c *Config) {
+		c.GrypeTargets = targets
+	}
+}
+
+func AllowlistFile(path string) Option {
+	return func(c *Config) {
+		c.Allowlist = path
+	}
+}
+
+// FailOn sets the minimum severity to fail the scan; the FAIL_ON_SEVERITY environment variable takes precedence
+func FailOn(severity Severity) Option {
+	return func(c *Config) {
+		c.FailOn = severity
+	}
+}
+
+func UnknownSeverity(severity Severity) Option {
+	return func(c *Config) {
+		c.UnknownSeverity = severity
+	}
+}
+
+// Scan runs the scanners, prints the findings and fails if any findings not allowed are at or above the FailOn severity
+func Scan(cfg Config) []Finding {
+	allowlist := ReadAllowlist(cfg.Allowlist)
+
+	var findings []Finding
+	for _, scanner := range cfg.Scanners {
+		switch scanner {
+		case Govulncheck:
+			findings = append(findings, ParseGovulncheck(Run("govulncheck -json ./...", run.Quiet()))...)
+		case Grype:
+			for _, target := range grypeTargets(cfg) {
+				findings = append(findings, ParseGrype(Run("grype -o json", run.Args(target), run.Quiet()))...)
+			}
+		default:
+			panic(fmt.Errorf("unsupported scanner: %s", scanner))
+		}
+	}
+	findings = dedupe(findings)
+
+	failing := 0
+	for i := range findings {
+		f := &findings[i]
+		if f.Severity == SeverityUnknown {
+			f.Severity = cfg.UnknownSeverity
+		}
+		f.Allowed = allowlist.Allows(*f)
+		if !f.Allowed && f.Severity >= cfg.FailOn {
+			failing++
+		}
+	}
+
+	if len(findings) == 0 {
+		Log("No vulnerabilities found")
+		return nil
+	}
+	printTable(findings, cfg.FailOn)
+
+	if failing > 0 {
+		panic(fmt.Errorf("%d vulnerabilities at or above %s severity", failing, cfg.FailOn))
+	}
+	return findings
+}
+
+func grypeTargets(cfg Config) []string {
+	if len(cfg.GrypeTargets) > 0 {
+		return cfg.GrypeTargets
+	}
+	targets := []string{"dir:" + gomod.Root()}
+	if file.IsDir(snapshotDir) {
+		targets = append(targets, "dir:"+snapshotDir)
+	}
+	return targets
+}
+
+// dedupe removes findings of the same vulnerability for the same package and version, such as when reported by
+// multiple scanners, keeping the highest severity
+func dedupe(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		idx := slices.IndexFunc(out, func(o Finding) bool {
+			return o.Package == f.Package && o.Version == f.Version && (o.Matches(f.ID) || f.Matches(o.ID))
+		})
+		if idx < 0 {
+			out = append(out, f)
+			continue
+		}
+		existing := &out[idx]
+		for _, id := range append([]string{f.ID}, f.Aliases...) {
+			if id != existing.ID && !slices.Contains(existing.Aliases, id) {
+				existing.Aliases = append(existing.Aliases, id)
+			}
+		}
+		existing.Severity = max(existing.Severity, f.Severity)
+		existing.FixedVersion = lang.Default(existing.FixedVersion, f.FixedVersion)
+	}
+	slices.SortStableFunc(out, func(a, b Finding) int {
+		if a.Severity != b.Severity {
+			return int(b.Severity - a.Severity)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out
+}
+
+func printTable(findings []Finding, failOn Severity) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	lang.Return(fmt.Fprintln(w, "ID\tSEVERITY\tPACKAGE\tVERSION\tFIXED IN\tSTATUS"))
+	for _, f := range findings {
+		status := "ok"
+		switch {
+		case f.Allowed:
+			status = "allowed"
+		case f.Severity >= failOn:
+			status = "fail"
+		}
+		lang.Return(fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", f.ID, f.Severity, f.Package, f.Version,
+			lang.Default(f.FixedVersion, "-"), status))
+	}
+	lang.Throw(w.Flush())
+	for _, f := range findings {
+		log.Debug("%s: %s %v", f.ID, f.Summary, f.Aliases)
+	}
+}
@@ -0,0 +1,124 @@
+package govuln
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_ParseGovulncheck(t *testing.T) {
+	findings := ParseGovulncheck(file.Read("testdata/govulncheck.json"))
+
+	// module level findings are not reported, and findings for multiple call paths are reported once
+	require.Equal(t, 1, len(findings))
+	f := findings[0]
+	require.Equal(t, "GO-2024-2611", f.ID)
+	require.Equal(t, "google.golang.org/protobuf", f.Package)
+	require.Equal(t, "v1.32.0", f.Version)
+	require.Equal(t, "v1.33.0", f.FixedVersion)
+	require.Equal(t, SeverityUnknown, f.Severity)
+	require.True(t, f.Matches("cve-2024-24786"))
+}
+
+func Test_ParseGrype(t *testing.T) {
+	findings := ParseGrype(file.Read("testdata/grype.json"))
+
+	require.Equal(t, 3, len(findings))
+	require.Equal(t, "GHSA-v778-237x-gjrc", findings[1].ID)
+	require.Equal(t, SeverityCritical, findings[1].Severity)
+	require.Equal(t, "0.31.0", findings[1].FixedVersion)
+	require.Equal(t, []string{"CVE-2024-45337"}, findings[1].Aliases)
+	require.Equal(t, "", findings[2].FixedVersion)
+}
+
+func Test_dedupe(t *testing.T) {
+	findings := dedupe(append(ParseGovulncheck(file.Read("testdata/govulncheck.json")), ParseGrype(file.Read("testdata/grype.json"))...))
+
+	require.Equal(t, 3, len(findings))
+	// sorted by severity
+	require.Equal(t, "GHSA-v778-237x-gjrc", findings[0].ID)
+	require.Equal(t, "GO-2024-2611", findings[1].ID)
+	require.Equal(t, SeverityMedium, findings[1].Severity)
+	require.True(t, findings[1].Matches("GHSA-8r3f-844c-mc37"))
+	require.Equal(t, "GHSA-xxxx-low", findings[2].ID)
+}
+
+func Test_ParseSeverity(t *testing.T) {
+	s, err := ParseSeverity("High")
+	require.NoError(t, err)
+	require.Equal(t, SeverityHigh, s)
+	require.Equal(t, "high", s.String())
+
+	_, err = ParseSeverity("severe")
+	require.Error(t, err)
+}
+
+func Test_Allowlist(t *testing.T) {
+	require.SetAndRestore(t, &now, func() time.Time {
+		return time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC)
+	})
+	path := filepath.Join(t.TempDir(), "allowlist.yaml")
+	file.Write(path, `allow:
+  - id: CVE-2024-24786
+    reason: not reachable
+    expires: "2025-06-30"
+  - id: GHSA-v778-237x-gjrc
+    package: golang.org/x/other
+  - id: GHSA-xxxx-low
+    expires: 2025-06-29
+`)
+	allowlist := ReadAllowlist(path)
+	findings := dedupe(ParseGrype(file.Read("testdata/grype.json")))
+
+	require.True(t, allowlist.Allows(findings[1]))  // by alias, through the expiry date
+	require.True(t, !allowlist.Allows(findings[0])) // different package
+	require.True(t, !allowlist.Allows(findings[2])) // expired
+
+	require.Equal(t, 0, len(ReadAllowlist(filepath.Join(t.TempDir(), "missing.yaml")).Allow))
+
+	file.Write(path, "allow:\n  - id: GO-2024-1\n    expires: next week\n")
+	require.Error(t, lang.Catch(func() { ReadAllowlist(path) }))
+}
+
+func Test_Scan(t *testing.T) {
+	if config.Windows {
+		t.Skip("fake scanners are shell scripts")
+	}
+	bin := t.TempDir()
+	for name, fixture := range map[string]string{"govulncheck": "govulncheck.json", "grype": "grype.json"} {
+		script := "#!/bin/sh\ncat " + lang.Return(filepath.Abs(filepath.Join("testdata", fixture))) + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0o700)) //nolint:gosec
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	allowlist := filepath.Join(t.TempDir(), "allowlist.yaml")
+	file.Write(allowlist, "allow:\n  - id: CVE-2024-45337\n")
+
+	cfg := defaultConfig()
+	AllowlistFile(allowlist)(&cfg)
+
+	// govulncheck findings have no severity
+	err := lang.Catch(func() { Scan(cfg) })
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 vulnerabilities at or above high severity")
+
+	UnknownSeverity(SeverityMedium)(&cfg)
+	require.Equal(t, 1, len(Scan(cfg)))
+
+	Scanners(Govulncheck, Grype)(&cfg)
+	GrypeTargets("dir:.")(&cfg)
+	findings := Scan(cfg)
+	require.Equal(t, 3, len(findings))
+	require.True(t, findings[0].Allowed)
+
+	FailOn(SeverityLow)(&cfg)
+	err = lang.Catch(func() { Scan(cfg) })
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2 vulnerabilities at or above low severity")
+}
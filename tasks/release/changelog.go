@@ -3,20 +3,101 @@ package release
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	. "github.com/anchore/go-make"
 	"github.com/anchore/go-make/binny"
+	"github.com/anchore/go-make/config"
 	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/github"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/template"
+	"github.com/anchore/go-make/version"
 )
 
 const (
 	changelogFile = "CHANGELOG.md"
 	versionFile   = "VERSION"
+
+	// unreleased is written to the version file when there are no changes to release
+	unreleased = "(Unreleased)"
+)
+
+// changelogTemplate is the markdown template the changelog is rendered with, see Changelog
+var changelogTemplate = `## {{ .Version }}
+{{- range .Sections }}
+
+### {{ .Title }}
+{{ range .Entries }}
+- {{ .Title }}{{ if .PR }} [[#{{ .PR }}]({{ .URL }})]{{ else if .URL }} [[{{ .SHA }}]({{ .URL }})]{{ else }} [{{ .SHA }}]{{ end }}{{ if .Author }} [@{{ .Author }}]{{ end }}
+{{- end }}
+{{- end }}
+{{- if .CompareURL }}
+
+**[(Full Changelog)]({{ .CompareURL }})**
+{{- end }}
+`
+
+// changelogCategories are the changelog sections in order; an entry is included in the first category it matches
+// by label or conventional commit type, and entries not matching any category are included in the last
+var changelogCategories = []changelogCategory{
+	{Title: "Breaking Changes", Bump: BumpMajor, Labels: []string{"breaking-change"}},
+	{Title: "Added Features", Bump: BumpMinor, Types: []string{"feat"}, Labels: []string{"enhancement", "feature"}},
+	{Title: "Bug Fixes", Bump: BumpPatch, Types: []string{"fix"}, Labels: []string{"bug"}},
+	{Title: "Additional Changes", Bump: BumpPatch},
+}
+
+var (
+	// NativeChangelog generates the changelog from the pull requests merged since the latest version tag, or the
+	// commits when the GitHub API is not available, rather than with chronicle
+	NativeChangelog = false
+
+	// changelogIgnoreLabels exclude pull requests from the changelog
+	changelogIgnoreLabels = []string{"changelog-ignore"}
+
+	// changelogIgnoreTypes exclude conventional commits without labels from the changelog
+	changelogIgnoreTypes = []string{"build", "chore", "ci", "style", "test"}
 )
 
+type changelogCategory struct {
+	Title  string
+	Bump   Bump
+	Types  []string
+	Labels []string
+}
+
+// Changelog is the set of changes since the previous release
+type Changelog struct {
+	Version    string
+	Previous   string
+	CompareURL string
+	Sections   []ChangelogSection
+}
+
+// ChangelogSection is a category of changes, such as bug fixes
+type ChangelogSection struct {
+	Title   string
+	Entries []ChangelogEntry
+}
+
+// ChangelogEntry is a single change: a merged pull request or, without one, a commit
+type ChangelogEntry struct {
+	Title  string
+	PR     int
+	URL    string
+	SHA    string
+	Author string
+
+	labels   []string
+	breaking bool
+}
+
 func ChangelogTask() Task {
 	return Task{
 		Name:        "changelog",
@@ -33,14 +114,21 @@ func ChangelogTask() Task {
 	}
 }
 
+// GenerateAndShowChangelog writes the changelog and next version files and shows the changelog, returning the file
+// paths. The changelog is generated with chronicle, or with GenerateChangelog when NativeChangelog is set
 func GenerateAndShowChangelog() (changelogFilePath, versionFilePath string) {
-	// gh auth status will fail the user is not authenticated
-	log.Debug(Run("gh auth status"))
-
-	ghAuthToken := Run("gh auth token")
-	log.Debug("Auth token: %.10s...", ghAuthToken)
-
-	changelog := Run("chronicle -n --version-file", run.Args(versionFile), run.Env("GITHUB_TOKEN", ghAuthToken))
+	var changelog string
+	if !NativeChangelog {
+		changelog = chronicleChangelog()
+	} else {
+		api := githubAPI()
+		if api == nil {
+			log.Info("No GitHub token or repository found, generating changelog from commits")
+		}
+		c := GenerateChangelog(api)
+		changelog = c.Render()
+		file.Write(versionFile, c.Version)
+	}
 
 	file.Write(changelogFile, changelog)
 
@@ -53,3 +141,187 @@ func GenerateAndShowChangelog() (changelogFilePath, versionFilePath string) {
 
 	return changelogFile, versionFile
 }
+
+func chronicleChangelog() string {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		// gh auth status will fail the user is not authenticated
+		log.Debug(Run("gh auth status"))
+		token = Run("gh auth token")
+	}
+	redact.Add(token)
+
+	return Run("chronicle -n --version-file", run.Args(versionFile), run.Env("GITHUB_TOKEN", token))
+}
+
+// GenerateChangelog returns the changes since the latest version tag, grouped by category, and the next version; if
+// HEAD is tagged with a release version, the changes since the prior release and the tagged version are returned. Pull
+// requests are looked up for each commit when a client is provided, otherwise only the commits are used
+func GenerateChangelog(api *github.Api) Changelog {
+	previous, current := latestRelease("HEAD")
+	released := ""
+	if tag, ok := version.Tag(); ok && tag == previous {
+		// HEAD is the release, such as when releasing a tag in CI, so include the changes since the prior release
+		released = previous
+		previous, current = "", version.Semver{}
+		if _, err := git.RevParse("HEAD^"); err == nil {
+			previous, current = latestRelease("HEAD^")
+		}
+	}
+	commits := lang.Return(git.CommitsSince(previous))
+
+	entries := changelogEntries(api, commits)
+
+	out := Changelog{
+		Version:  unreleased,
+		Previous: previous,
+	}
+	if len(commits) > 0 {
+		bump := BumpPatch
+		for _, category := range changelogCategories {
+			var sectionEntries []ChangelogEntry
+			entries = slices.DeleteFunc(entries, func(e ChangelogEntry) bool {
+				if category.matches(e) {
+					sectionEntries = append(sectionEntries, e)
+					return true
+				}
+				return false
+			})
+			if len(sectionEntries) == 0 {
+				continue
+			}
+			if bumpOrder(category.Bump) > bumpOrder(bump) {
+				bump = category.Bump
+			}
+			out.Sections = append(out.Sections, ChangelogSection{Title: category.Title, Entries: sectionEntries})
+		}
+		out.Version = lang.Default(released, NextVersion(current, inferredBump(current, bump)).String())
+	}
+
+	if api != nil && previous != "" && out.Version != unreleased {
+		out.CompareURL = fmt.Sprintf("%s/%s/compare/%s...%s", githubServerURL(), api.Repo, previous, out.Version)
+	}
+	return out
+}
+
+// Render returns the changelog as markdown
+func (c Changelog) Render() string {
+	return template.Render(changelogTemplate, map[string]any{
+		"Version":    c.Version,
+		"Previous":   c.Previous,
+		"CompareURL": c.CompareURL,
+		"Sections":   c.Sections,
+	})
+}
+
+func changelogEntries(api *github.Api, commits []git.Commit) []ChangelogEntry {
+	var out []ChangelogEntry
+	seen := map[int]bool{}
+	for _, c := range commits {
+		entry := ChangelogEntry{
+			Title:    c.Subject,
+			SHA:      c.SHA[:min(len(c.SHA), 7)],
+			breaking: isBreaking(c),
+		}
+		if api != nil {
+			entry.URL = fmt.Sprintf("%s/%s/commit/%s", githubServerURL(), api.Repo, c.SHA)
+			pr, err := mergedPullRequest(api, c.SHA)
+			if err != nil {
+				log.Debug("unable to find pull request for commit %s: %v", c.SHA, err)
+			}
+			if pr != nil {
+				if seen[pr.Number] {
+					continue
+				}
+				seen[pr.Number] = true
+				entry.Title = pr.Title
+				entry.PR = pr.Number
+				entry.URL = pr.HTMLURL
+				entry.Author = pr.User.Login
+				for _, label := range pr.Labels {
+					entry.labels = append(entry.labels, strings.ToLower(label.Name))
+				}
+				entry.breaking = entry.breaking || isBreaking(git.Commit{Subject: pr.Title, Body: pr.Body})
+			}
+		}
+		if isIgnored(entry) {
+			log.Debug("excluding from changelog: %s", entry.Title)
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// mergedPullRequest returns the pull request the commit was merged by, or nil if there is none
+func mergedPullRequest(api *github.Api, sha string) (*github.PullRequest, error) {
+	prs, err := api.PullRequestsForCommit(sha)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		if pr.MergeCommit == sha || pr.Merged || !time.Time(pr.MergedAt).IsZero() {
+			return &pr, nil
+		}
+	}
+	return nil, nil
+}
+
+func isBreaking(c git.Commit) bool {
+	return BumpFor([]git.Commit{c}) == BumpMajor
+}
+
+func isIgnored(e ChangelogEntry) bool {
+	for _, label := range changelogIgnoreLabels {
+		if slices.Contains(e.labels, label) {
+			return true
+		}
+	}
+	return len(e.labels) == 0 && !e.breaking && slices.Contains(changelogIgnoreTypes, commitType(e.Title))
+}
+
+func (c changelogCategory) matches(e ChangelogEntry) bool {
+	if c.Types == nil && c.Labels == nil {
+		return true // the catch-all category
+	}
+	if c.Bump == BumpMajor && e.breaking {
+		return true
+	}
+	for _, label := range c.Labels {
+		if slices.Contains(e.labels, label) {
+			return true
+		}
+	}
+	return slices.Contains(c.Types, commitType(e.Title))
+}
+
+// commitType returns the conventional commit type of the message, e.g. "feat", or "" if it is not conventional
+func commitType(message string) string {
+	parts := conventionalCommitPattern.FindStringSubmatch(message)
+	if parts == nil {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
+func bumpOrder(bump Bump) int {
+	return slices.Index([]Bump{BumpNone, BumpPatch, BumpMinor, BumpMajor}, bump)
+}
+
+// githubAPI returns a client from github.NewClient, or nil if no token or repository is available, such as when gh
+// is not installed or not authenticated
+func githubAPI() *github.Api {
+	var api github.Api
+	err := lang.Catch(func() {
+		api = github.NewClient()
+	})
+	if err != nil || api.Token == "" || api.Repo == "" {
+		log.Debug("GitHub API not available: %v", err)
+		return nil
+	}
+	return &api
+}
+
+func githubServerURL() string {
+	return config.Env("GITHUB_SERVER_URL", "https://github.com")
+}
@@ -0,0 +1,109 @@
+package release
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_GenerateChangelogOffline(t *testing.T) {
+	dir := require.GitRepo(t)
+	file.InDir(dir, func() {
+		c := GenerateChangelog(nil)
+		require.Equal(t, "v0.0.1", c.Version)
+
+		require.Git(t, dir, "tag", "-a", "v0.1.0", "-m", "v0.1.0")
+		c = GenerateChangelog(nil)
+		require.Equal(t, "v0.1.0", c.Version)
+		require.Equal(t, "", c.Previous)
+		require.Equal(t, "initial commit", c.Sections[0].Entries[0].Title)
+
+		require.GitCommit(t, dir, "fix: a bug", nil)
+		require.GitCommit(t, dir, "chore(deps): update", nil)
+		require.GitCommit(t, dir, "feat(cli): new flag", nil)
+		require.GitCommit(t, dir, "update docs", nil)
+		c = GenerateChangelog(nil)
+		require.Equal(t, "v0.2.0", c.Version)
+		require.Equal(t, "v0.1.0", c.Previous)
+		require.Equal(t, "", c.CompareURL)
+		require.Equal(t, []string{"Added Features", "Bug Fixes", "Additional Changes"}, sectionTitles(c))
+		require.Equal(t, "feat(cli): new flag", c.Sections[0].Entries[0].Title)
+
+		// breaking changes before v1.0.0 are a minor bump
+		require.GitCommit(t, dir, "refactor!: remove option", nil)
+		c = GenerateChangelog(nil)
+		require.Equal(t, "v0.2.0", c.Version)
+		require.Equal(t, "Breaking Changes", c.Sections[0].Title)
+
+		out := c.Render()
+		require.True(t, strings.HasPrefix(out, "## v0.2.0\n"))
+		require.Contains(t, out, "### Bug Fixes\n\n- fix: a bug [")
+		require.True(t, !strings.Contains(out, "chore(deps)"))
+
+		// HEAD tagged as the release includes the changes since the prior release
+		require.Git(t, dir, "tag", "-a", "v0.2.0", "-m", "v0.2.0")
+		c = GenerateChangelog(nil)
+		require.Equal(t, "v0.2.0", c.Version)
+		require.Equal(t, "v0.1.0", c.Previous)
+		require.Equal(t, []string{"Breaking Changes", "Added Features", "Bug Fixes", "Additional Changes"}, sectionTitles(c))
+	})
+}
+
+func Test_GenerateChangelogPullRequests(t *testing.T) {
+	dir := require.GitRepo(t)
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example")
+
+	file.InDir(dir, func() {
+		require.Git(t, dir, "tag", "-a", "v1.2.3", "-m", "v1.2.3")
+		require.GitCommit(t, dir, "add a flag (#10)", nil)
+		flag := require.Git(t, dir, "rev-parse", "HEAD")
+		require.GitCommit(t, dir, "first part", nil)
+		part1 := require.Git(t, dir, "rev-parse", "HEAD")
+		require.GitCommit(t, dir, "second part", nil)
+		part2 := require.Git(t, dir, "rev-parse", "HEAD")
+		require.GitCommit(t, dir, "bump deps (#12)", nil)
+		deps := require.Git(t, dir, "rev-parse", "HEAD")
+		require.GitCommit(t, dir, "fix: direct push", nil)
+		direct := require.Git(t, dir, "rev-parse", "HEAD")
+
+		split := github.PullRequest{Number: 11, Title: "Fix crash", HTMLURL: "https://github.example/owner/repo/pull/11",
+			Merged: true, User: github.User{Login: "dev2"}, Labels: []github.Label{{Name: "Bug"}}}
+		url := require.Server(t, map[string]any{
+			"/repos/owner/repo/commits/" + flag + "/pulls": []github.PullRequest{{Number: 10, Title: "Add a flag",
+				HTMLURL: "https://github.example/owner/repo/pull/10", MergeCommit: flag,
+				User: github.User{Login: "dev1"}, Labels: []github.Label{{Name: "enhancement"}}}},
+			"/repos/owner/repo/commits/" + part1 + "/pulls": []github.PullRequest{split},
+			"/repos/owner/repo/commits/" + part2 + "/pulls": []github.PullRequest{split},
+			"/repos/owner/repo/commits/" + deps + "/pulls": []github.PullRequest{{Number: 12, Title: "Bump deps",
+				MergeCommit: deps, Labels: []github.Label{{Name: "changelog-ignore"}}}},
+			"/repos/owner/repo/commits/" + direct + "/pulls": []github.PullRequest{},
+		})
+
+		c := GenerateChangelog(&github.Api{BaseURL: url, Repo: "owner/repo"})
+		require.Equal(t, "v1.3.0", c.Version)
+		require.Equal(t, "https://github.example/owner/repo/compare/v1.2.3...v1.3.0", c.CompareURL)
+		require.Equal(t, []string{"Added Features", "Bug Fixes"}, sectionTitles(c))
+
+		fixes := c.Sections[1].Entries
+		require.Equal(t, 2, len(fixes))
+		require.Equal(t, "fix: direct push", fixes[0].Title)
+		require.Equal(t, "https://github.example/owner/repo/commit/"+direct, fixes[0].URL)
+		require.Equal(t, 11, fixes[1].PR)
+
+		out := c.Render()
+		require.Contains(t, out, "- Add a flag [[#10](https://github.example/owner/repo/pull/10)] [@dev1]")
+		require.Contains(t, out, "**[(Full Changelog)](https://github.example/owner/repo/compare/v1.2.3...v1.3.0)**")
+		require.True(t, !strings.Contains(out, "Bump deps"))
+	})
+}
+
+func sectionTitles(c Changelog) []string {
+	var out []string
+	for _, s := range c.Sections {
+		out = append(out, s.Title)
+	}
+	return out
+}
@@ -6,6 +6,8 @@ import (
 
 	. "github.com/anchore/go-make"
 	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/run"
 	"github.com/anchore/go-make/script"
 )
@@ -16,7 +18,7 @@ func GhReleaseTask() Task {
 		Description: "creates a GitHub release",
 		Run: func() {
 			// get all up-to-date tags from the server
-			Run("git fetch --tags --prune --prune-tags")
+			lang.Throw(git.FetchTags(git.Prune()))
 
 			changelogFile, versionFile := GenerateAndShowChangelog()
 
@@ -32,15 +34,15 @@ func GhReleaseTask() Task {
 			)
 
 			// tag "latest" to the same version:
-			Run("git fetch --tags")
+			lang.Throw(git.FetchTags())
 
-			commit := Run("git rev-parse", run.Args(version))
+			commit := lang.Return(git.RevParse(version))
 
 			// Replace the tag to reference the tag's commit
-			Run("git tag -fa -m", run.Args("create tag: "+version, "latest", commit))
+			lang.Throw(git.CreateTag("latest", "create tag: "+version, git.At(commit), git.Force()))
 
 			// Push the tag to the remote origin
-			Run("git push origin -f --tags refs/tags/latest:refs/tags/latest")
+			lang.Throw(git.PushTag("origin", "latest", git.Force()))
 		},
 	}
 }
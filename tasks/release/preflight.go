@@ -0,0 +1,279 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/binny"
+	"github.com/anchore/go-make/color"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/version"
+)
+
+const goreleaserConfig = ".goreleaser.yaml"
+
+// PreflightCheck is a named release check, which panics when the check fails
+type PreflightCheck struct {
+	Name  string
+	Check func()
+}
+
+type PreflightConfig struct {
+	// Tools must be managed by and installable with binny, defaults to the ReleaseTools and changelog tool managed by
+	// binny
+	Tools []string
+	// ReleaseTools are the tools used by the release task, such as goreleaser
+	ReleaseTools []string
+	// RequiredChecks are glob patterns matching the commit statuses and check runs which must pass for HEAD,
+	// defaults to *test*
+	RequiredChecks []string
+	// Checks are additional checks to run
+	Checks []PreflightCheck
+}
+
+type PreflightOption func(*PreflightConfig)
+
+// PreflightTools sets the tools which must be installable with binny
+func PreflightTools(tools ...string) PreflightOption {
+	return func(c *PreflightConfig) {
+		c.Tools = tools
+	}
+}
+
+// ReleaseTools sets the tools used by the release task, which must be installable if managed by binny
+func ReleaseTools(tools ...string) PreflightOption {
+	return func(c *PreflightConfig) {
+		c.ReleaseTools = tools
+	}
+}
+
+// RequiredChecks sets the glob patterns of commit statuses and check runs which must pass for HEAD
+func RequiredChecks(patterns ...string) PreflightOption {
+	return func(c *PreflightConfig) {
+		c.RequiredChecks = patterns
+	}
+}
+
+// PreflightChecks adds checks to run in addition to the default checks
+func PreflightChecks(checks ...PreflightCheck) PreflightOption {
+	return func(c *PreflightConfig) {
+		c.Checks = append(c.Checks, checks...)
+	}
+}
+
+// PreflightTask verifies a release can be published, see Preflight. The version is read from the VERSION environment
+// variable, the version tag at HEAD or the VERSION file. The release tasks do not run these checks, run this task first
+// to opt in, e.g.: make release:preflight ci-release
+func PreflightTask(opts ...PreflightOption) Task {
+	return Task{
+		Name:        "release:preflight",
+		Description: "verify a release can be published",
+		Run: func() {
+			Preflight(releaseVersion(), opts...)
+		},
+	}
+}
+
+// Preflight runs all release checks for the version, reporting all failures together: the working tree is clean,
+// HEAD is merged to the remote default branch, the version has no published release, release tools are installable, the
+// goreleaser config is valid, go.mod has no replace directives and tests passed for HEAD
+func Preflight(releaseVersion string, opts ...PreflightOption) {
+	cfg := PreflightConfig{
+		RequiredChecks: []string{"*test*"},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	api := githubAPI()
+	checks := append([]PreflightCheck{
+		{"working tree is clean", checkClean},
+		{"HEAD is on the remote default branch", checkDefaultBranch},
+		{"release is not published", func() { checkNotPublished(api, releaseVersion) }},
+		{"release tools are installable", func() { checkTools(cfg) }},
+		{"goreleaser config is valid", checkGoreleaserConfig},
+		{"go.mod has no replace directives", checkNoReplace},
+		{"tests passed", func() { checkTestsPassed(api, cfg.RequiredChecks) }},
+	}, cfg.Checks...)
+
+	log.Info("Running release preflight checks for %s", lang.Default(releaseVersion, "unknown version"))
+
+	var failed []string
+	for _, c := range checks {
+		err := lang.Catch(c.Check)
+		if err != nil {
+			log.Info(color.Red("  ✘ %s: %v"), c.Name, unwrapStackTrace(err))
+			failed = append(failed, c.Name)
+			continue
+		}
+		log.Info(color.Green("  ✔ %s"), c.Name)
+	}
+
+	if len(failed) > 0 {
+		panic(fmt.Errorf("%d of %d release preflight checks failed: %s", len(failed), len(checks), strings.Join(failed, ", ")))
+	}
+}
+
+func releaseVersion() string {
+	if v := os.Getenv("VERSION"); v != "" {
+		return v
+	}
+	if tag, ok := version.Tag(); ok {
+		return tag
+	}
+	if file.Exists(versionFile) {
+		return strings.TrimSpace(file.Read(versionFile))
+	}
+	return ""
+}
+
+func checkClean() {
+	if lang.Return(git.IsDirty()) {
+		panic(errors.New("working tree has uncommitted changes"))
+	}
+}
+
+func checkDefaultBranch() {
+	defaultBranch, err := git.DefaultBranch(remote)
+	if err != nil {
+		log.Debug("unable to determine default branch, assuming main: %v", err)
+		defaultBranch = "main"
+	}
+	head := lang.Return(git.RevParse("HEAD"))
+	remoteHead := lang.Return(git.RemoteRevision(remote, defaultBranch))
+	if head == remoteHead {
+		return
+	}
+	// the default branch may have moved past HEAD, such as when releasing a tag in CI
+	lang.Throw(git.Fetch(remote, defaultBranch))
+	if !lang.Return(git.IsAncestor(head, remoteHead)) {
+		panic(fmt.Errorf("HEAD %.7s is not on %s/%s at %.7s", head, remote, defaultBranch, remoteHead))
+	}
+}
+
+func checkNotPublished(api *github.Api, releaseVersion string) {
+	if _, err := version.Parse(releaseVersion); err != nil {
+		panic(fmt.Errorf("invalid release version %q: %w", releaseVersion, err))
+	}
+	if api == nil {
+		panic(errors.New("GitHub token or repository not found"))
+	}
+	release := lang.Return(api.ReleaseByTag(releaseVersion))
+	if release != nil {
+		panic(fmt.Errorf("%s is already released: %s", releaseVersion, release.HTMLURL))
+	}
+}
+
+func checkTools(cfg PreflightConfig) {
+	tools := cfg.Tools
+	if tools == nil {
+		releaseTools := cfg.ReleaseTools
+		if !NativeChangelog {
+			releaseTools = append(slices.Clone(releaseTools), "chronicle")
+		}
+		tools = lang.Remove(releaseTools, func(tool string) bool {
+			return !binny.IsManagedTool(tool)
+		})
+	}
+	var errs []error
+	for _, tool := range tools {
+		if !binny.IsManagedTool(tool) {
+			errs = append(errs, fmt.Errorf("%s is not managed by binny", tool))
+			continue
+		}
+		if err := lang.Catch(func() { binny.Install(tool) }); err != nil {
+			errs = append(errs, fmt.Errorf("unable to install %s: %w", tool, err))
+		}
+	}
+	lang.Throw(errors.Join(errs...))
+}
+
+func checkGoreleaserConfig() {
+	if !file.Exists(goreleaserConfig) {
+		log.Debug("no %s found", goreleaserConfig)
+		return
+	}
+	Run("goreleaser check", run.Args("--config", goreleaserConfig), run.Quiet())
+}
+
+func checkNoReplace() {
+	m := gomod.Read()
+	if m == nil || len(m.Replace) == 0 {
+		return
+	}
+	var replaced []string
+	for _, r := range m.Replace {
+		replaced = append(replaced, r.Old.Path)
+	}
+	panic(fmt.Errorf("go.mod replaces: %s", strings.Join(replaced, ", ")))
+}
+
+// checkTestsPassed verifies at least one commit status or check run for HEAD matches the patterns, and all matching
+// statuses and check runs completed successfully
+func checkTestsPassed(api *github.Api, patterns []string) {
+	if api == nil {
+		panic(errors.New("GitHub token or repository not found"))
+	}
+	head := lang.Return(git.RevParse("HEAD"))
+	matches := func(name string) bool {
+		for _, pattern := range patterns {
+			// check names often include slashes, e.g. "Validations / Unit tests", so * matches any characters
+			expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+			if regexp.MustCompile(expr).MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := 0
+	var failed []string
+	for _, s := range lang.Return(api.CombinedStatus(head)).Statuses {
+		if !matches(s.Context) {
+			continue
+		}
+		found++
+		if s.State != "success" {
+			failed = append(failed, fmt.Sprintf("%s (%s)", s.Context, s.State))
+		}
+	}
+	for _, r := range lang.Return(api.CheckRuns(head)) {
+		if !matches(r.Name) {
+			continue
+		}
+		found++
+		switch {
+		case r.Status != "completed":
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Name, r.Status))
+		case r.Conclusion != "success" && r.Conclusion != "skipped" && r.Conclusion != "neutral":
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Name, r.Conclusion))
+		}
+	}
+
+	if found == 0 {
+		panic(fmt.Errorf("no checks matching %s found for %.7s", strings.Join(patterns, ", "), head))
+	}
+	if len(failed) > 0 {
+		panic(fmt.Errorf("checks not passed for %.7s: %s", head, strings.Join(failed, ", ")))
+	}
+}
+
+// unwrapStackTrace returns the underlying error to report, without a stack trace
+func unwrapStackTrace(err error) error {
+	var stackErr *lang.StackTraceError
+	if errors.As(err, &stackErr) && stackErr.Err != nil {
+		return stackErr.Err
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Preflight(t *testing.T) {
+	dir := require.GitRepo(t)
+	origin := t.TempDir()
+	require.Git(t, origin, "init", "--bare", "--initial-branch=main")
+	require.Git(t, dir, "remote", "add", "origin", origin)
+	require.GitCommit(t, dir, "add module", map[string]string{
+		"go.mod": "module example.com/test\n\ngo 1.24\n\nreplace example.com/dep => ../dep\n",
+	})
+	require.Git(t, dir, "push", "-u", "origin", "main")
+
+	head := require.Git(t, dir, "rev-parse", "HEAD")
+	url := require.Server(t, map[string]any{
+		"/repos/owner/repo/releases/tags/v1.0.0": github.Release{TagName: "v1.0.0", HTMLURL: "https://github.com/owner/repo/releases/v1.0.0"},
+		"/repos/owner/repo/commits/" + head + "/status": github.CombinedStatus{State: "success", Statuses: []github.CommitStatus{
+			{Context: "lint", State: "failure"},
+		}},
+		"/repos/owner/repo/commits/" + head + "/check-runs?per_page=100": github.CheckRunList{CheckRuns: []github.CheckRun{
+			{Name: "Validations / Unit tests", Status: "completed", Conclusion: "success"},
+			{Name: "Validations / Integration tests", Status: "completed", Conclusion: "skipped"},
+		}},
+	})
+	t.Setenv("GITHUB_TOKEN", "the-token")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_API_URL", url)
+
+	file.InDir(dir, func() {
+		err := lang.Catch(func() {
+			Preflight("v1.0.0", PreflightTools("not-a-managed-tool"))
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "3 of 7 release preflight checks failed: release is not published, "+
+			"release tools are installable, go.mod has no replace directives")
+
+		api := githubAPI()
+		require.NoError(t, lang.Catch(func() { checkNotPublished(api, "v1.1.0") }))
+		require.Error(t, lang.Catch(func() { checkNotPublished(api, "not-a-version") }))
+
+		// failing checks not matching the required checks are ignored
+		require.Error(t, lang.Catch(func() { checkTestsPassed(api, []string{"lint"}) }))
+		require.Error(t, lang.Catch(func() { checkTestsPassed(api, []string{"e2e*"}) }))
+
+		// HEAD must be on the remote default branch, which may have moved past HEAD
+		require.NoError(t, lang.Catch(checkDefaultBranch))
+		require.GitCommit(t, dir, "pushed", nil)
+		require.Git(t, dir, "push", "origin", "main")
+		require.Git(t, dir, "checkout", "-q", head)
+		require.NoError(t, lang.Catch(checkDefaultBranch))
+
+		require.Git(t, dir, "checkout", "-q", "main")
+		require.GitCommit(t, dir, "not pushed", nil)
+		err = lang.Catch(checkDefaultBranch)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not on origin/main")
+
+		// release tools default to the tools managed by binny
+		require.SetAndRestore(t, &NativeChangelog, true)
+		require.NoError(t, lang.Catch(func() { checkTools(PreflightConfig{ReleaseTools: []string{"not-a-managed-tool"}}) }))
+		require.Error(t, lang.Catch(func() { checkTools(PreflightConfig{Tools: []string{"not-a-managed-tool"}}) }))
+
+		require.NoError(t, lang.Catch(checkClean))
+		file.Write("go.mod", "module example.com/test\n")
+		require.Error(t, lang.Catch(checkClean))
+	})
+}
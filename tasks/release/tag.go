@@ -0,0 +1,172 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/script"
+	"github.com/anchore/go-make/version"
+)
+
+// Bump is the kind of version increment for a release
+type Bump string
+
+const (
+	BumpNone  Bump = ""
+	BumpPatch Bump = "patch"
+	BumpMinor Bump = "minor"
+	BumpMajor Bump = "major"
+)
+
+const remote = "origin"
+
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s`)
+
+// TagTask creates an annotated version tag for the next version, determined by the conventional commit messages
+// since the latest version tag, or the BUMP environment variable: major, minor or patch. Before v1.0.0, inferred
+// breaking changes bump the minor version. The tag is pushed when the PUSH environment variable is true.
+func TagTask() Task {
+	tagTask := func(bump Bump) func() {
+		return func() {
+			if bump == BumpNone {
+				bump = Bump(strings.ToLower(os.Getenv("BUMP")))
+			}
+			push, _ := strconv.ParseBool(config.Env("PUSH", "false"))
+			CreateVersionTag(bump, push)
+		}
+	}
+	return Task{
+		Name:        "release:tag",
+		Description: "tag the next version based on conventional commits",
+		Run:         tagTask(BumpNone),
+		Tasks: []Task{
+			{
+				Name:        "release:tag:major",
+				Description: "tag the next major version",
+				Run:         tagTask(BumpMajor),
+			},
+			{
+				Name:        "release:tag:minor",
+				Description: "tag the next minor version",
+				Run:         tagTask(BumpMinor),
+			},
+			{
+				Name:        "release:tag:patch",
+				Description: "tag the next patch version",
+				Run:         tagTask(BumpPatch),
+			},
+		},
+	}
+}
+
+// CreateVersionTag validates the working tree is clean and on the default branch, then creates an annotated tag for
+// the next version and optionally pushes it, returning the new tag. If bump is BumpNone, it is determined from the
+// commits since the latest version tag, see BumpFor
+func CreateVersionTag(bump Bump, push bool) string {
+	ensureReleasable()
+
+	latest, current := latestRelease("HEAD")
+	commits := lang.Return(git.CommitsSince(latest))
+	if len(commits) == 0 {
+		panic(fmt.Errorf("no commits since %s", latest))
+	}
+	if bump == BumpNone {
+		bump = inferredBump(current, BumpFor(commits))
+	}
+	next := NextVersion(current, bump).String()
+
+	log.Info("Tagging %s as %s (%s bump from %s, %d commits)", git.Revision(), next, bump, lang.Default(latest, "no tag"), len(commits))
+	lang.Throw(git.CreateTag(next, "release "+next))
+
+	if push {
+		if !config.CI {
+			script.Confirm("Do you want to push tag '%s' to %s?", next, remote)
+		}
+		lang.Throw(git.PushTag(remote, next))
+	}
+	return next
+}
+
+// BumpFor returns the bump indicated by conventional commit messages: major for breaking changes, e.g. "feat!:" or
+// a "BREAKING CHANGE:" footer, minor for features, and patch otherwise
+func BumpFor(commits []git.Commit) Bump {
+	bump := BumpPatch
+	for _, c := range commits {
+		if strings.Contains(c.Body, "BREAKING CHANGE:") || strings.Contains(c.Body, "BREAKING-CHANGE:") {
+			return BumpMajor
+		}
+		parts := conventionalCommitPattern.FindStringSubmatch(c.Subject)
+		if parts == nil {
+			continue
+		}
+		if parts[2] == "!" {
+			return BumpMajor
+		}
+		if parts[1] == "feat" {
+			bump = BumpMinor
+		}
+	}
+	return bump
+}
+
+// latestRelease returns the latest release tag merged into the ref and its version, or an empty tag and v0.0.0
+func latestRelease(ref string) (string, version.Semver) {
+	latest, err := git.LatestSemverTagAt(ref)
+	switch {
+	case errors.Is(err, git.ErrNoTags):
+		log.Info("No version tags found")
+		return "", version.Semver{}
+	case err != nil:
+		panic(err)
+	}
+	return latest, lang.Return(version.Parse(latest))
+}
+
+// inferredBump adjusts a bump inferred from changes: before v1.0.0, breaking changes are released as a minor version,
+// whereas an explicit major bump releases v1
+func inferredBump(current version.Semver, bump Bump) Bump {
+	if bump == BumpMajor && current.Major == 0 {
+		return BumpMinor
+	}
+	return bump
+}
+
+// NextVersion returns the version following current for the bump
+func NextVersion(current version.Semver, bump Bump) version.Semver {
+	switch bump {
+	case BumpMajor:
+		return current.NextMajor()
+	case BumpMinor:
+		return current.NextMinor()
+	case BumpPatch:
+		return current.NextPatch()
+	}
+	panic(fmt.Errorf("invalid version bump: %q, expected one of: major, minor, patch", bump))
+}
+
+func ensureReleasable() {
+	if lang.Return(git.IsDirty()) {
+		panic(errors.New("working tree has uncommitted changes"))
+	}
+	branch, err := git.Branch()
+	if err != nil {
+		panic(fmt.Errorf("unable to determine branch: %w", err))
+	}
+	defaultBranch, err := git.DefaultBranch(remote)
+	if err != nil {
+		log.Debug("unable to determine default branch, assuming main: %v", err)
+		defaultBranch = "main"
+	}
+	if branch != defaultBranch {
+		panic(fmt.Errorf("releases must be tagged on the default branch %s, currently on: %s", defaultBranch, branch))
+	}
+}
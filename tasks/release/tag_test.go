@@ -0,0 +1,119 @@
+package release
+
+import (
+	"os"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/version"
+)
+
+func Test_BumpFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		commits  []git.Commit
+		expected Bump
+	}{
+		{
+			name:     "fixes and chores",
+			commits:  []git.Commit{{Subject: "fix: a bug"}, {Subject: "chore(deps): update"}, {Subject: "not conventional"}},
+			expected: BumpPatch,
+		},
+		{
+			name:     "feature",
+			commits:  []git.Commit{{Subject: "fix: a bug"}, {Subject: "feat(cli): new flag"}},
+			expected: BumpMinor,
+		},
+		{
+			name:     "breaking marker",
+			commits:  []git.Commit{{Subject: "feat: new flag"}, {Subject: "refactor(api)!: remove method"}},
+			expected: BumpMajor,
+		},
+		{
+			name:     "breaking footer",
+			commits:  []git.Commit{{Subject: "fix: a bug", Body: "details\n\nBREAKING CHANGE: config renamed"}},
+			expected: BumpMajor,
+		},
+		{
+			name:     "feat without separator is not conventional",
+			commits:  []git.Commit{{Subject: "feature flags:cleanup"}},
+			expected: BumpPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, BumpFor(tt.commits))
+		})
+	}
+}
+
+func Test_NextVersion(t *testing.T) {
+	v1 := lang.Return(version.Parse("v1.2.3"))
+	v0 := lang.Return(version.Parse("v0.4.1"))
+
+	require.Equal(t, "v2.0.0", NextVersion(v1, BumpMajor).String())
+	require.Equal(t, "v1.3.0", NextVersion(v1, BumpMinor).String())
+	require.Equal(t, "v1.2.4", NextVersion(v1, BumpPatch).String())
+	require.Equal(t, "v1.0.0", NextVersion(v0, BumpMajor).String())
+
+	require.Error(t, lang.Catch(func() {
+		NextVersion(v1, "huge")
+	}))
+}
+
+func Test_CreateVersionTag(t *testing.T) {
+	dir := require.GitRepo(t)
+	origin := t.TempDir()
+	require.Git(t, origin, "init", "--bare", "--initial-branch=main")
+	require.Git(t, dir, "remote", "add", "origin", origin)
+	require.Git(t, dir, "push", "-u", "origin", "main")
+	require.Git(t, dir, "remote", "set-head", "origin", "main")
+
+	file.InDir(dir, func() {
+		require.GitCommit(t, dir, "feat: first feature", map[string]string{"a.txt": "a"})
+		require.Equal(t, "v0.1.0", CreateVersionTag(BumpNone, false))
+
+		// no commits since the last tag
+		require.Error(t, lang.Catch(func() {
+			CreateVersionTag(BumpNone, false)
+		}))
+
+		require.GitCommit(t, dir, "fix: a bug", map[string]string{"a.txt": "b"})
+		require.Equal(t, "v0.1.1", CreateVersionTag(BumpNone, false))
+
+		// breaking changes before v1.0.0 are a minor bump
+		require.GitCommit(t, dir, "feat!: breaking", nil)
+		require.Equal(t, "v0.2.0", CreateVersionTag(BumpNone, false))
+
+		require.GitCommit(t, dir, "docs: readme", nil)
+		require.Equal(t, "v1.0.0", CreateVersionTag(BumpMajor, false))
+
+		// pushing does not prompt in CI
+		require.GitCommit(t, dir, "feat!: breaking", nil)
+		require.SetAndRestore(t, &config.CI, true)
+		require.Equal(t, "v2.0.0", CreateVersionTag(BumpNone, true))
+		require.Equal(t, lang.Return(git.RevParse("v2.0.0")), require.Git(t, origin, "rev-parse", "v2.0.0^{commit}"))
+
+		// dirty working tree
+		require.NoError(t, os.WriteFile("a.txt", []byte("dirty"), 0o600))
+		err := lang.Catch(func() {
+			CreateVersionTag(BumpPatch, false)
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "uncommitted changes")
+		require.Git(t, dir, "checkout", "a.txt")
+
+		// not on the default branch
+		require.Git(t, dir, "checkout", "-b", "feature")
+		require.GitCommit(t, dir, "feat: on a branch", nil)
+		err = lang.Catch(func() {
+			CreateVersionTag(BumpNone, false)
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "default branch main")
+	})
+}
@@ -10,9 +10,11 @@ import (
 
 	. "github.com/anchore/go-make"
 	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
 	"github.com/anchore/go-make/gomod"
 	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/redact"
 	"github.com/anchore/go-make/run"
 	"github.com/anchore/go-make/script"
 )
@@ -46,11 +48,12 @@ func WorkflowReleaseTask() Task {
 			githubToken := os.Getenv("GITHUB_TOKEN")
 			if githubToken == "" {
 				githubToken = Run("gh auth token")
+				redact.Add(githubToken)
 				lang.Throw(os.Setenv("GITHUB_TOKEN", githubToken))
 			}
 
 			// ensure we have up-to-date git tags
-			Run("git fetch --tags")
+			lang.Throw(git.FetchTags())
 
 			GenerateAndShowChangelog()
 
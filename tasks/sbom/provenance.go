@@ -0,0 +1,140 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+)
+
+// ProvenanceFile is the name of the provenance statement written to the DistDir
+const ProvenanceFile = "provenance.intoto.jsonl"
+
+const (
+	statementType  = "https://in-toto.io/Statement/v1"
+	predicateType  = "https://slsa.dev/provenance/v1"
+	buildType      = "https://github.com/anchore/go-make/sbom@v1"
+	localBuilderID = "https://github.com/anchore/go-make"
+)
+
+// Statement is an in-toto attestation statement, see: https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+type Statement struct {
+	Type          string     `json:"_type"`
+	Subject       []Resource `json:"subject"`
+	PredicateType string     `json:"predicateType"`
+	Predicate     Provenance `json:"predicate"`
+}
+
+// Provenance is a SLSA provenance predicate, see: https://slsa.dev/spec/v1.0/provenance
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string            `json:"buildType"`
+	ExternalParameters   map[string]string `json:"externalParameters"`
+	InternalParameters   map[string]string `json:"internalParameters,omitempty"`
+	ResolvedDependencies []Resource        `json:"resolvedDependencies,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+type BuildMetadata struct {
+	InvocationID string `json:"invocationId,omitempty"`
+	FinishedOn   string `json:"finishedOn,omitempty"`
+}
+
+// Resource is an in-toto resource descriptor, used for subjects and dependencies
+type Resource struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// WriteProvenance writes a provenance statement with the files as subjects to the path, returning the path
+func WriteProvenance(path string, subjects []string) string {
+	statement := NewProvenance(subjects)
+	contents := lang.Return(json.Marshal(statement))
+	// in-toto JSON lines, one statement per line
+	file.Write(path, string(contents)+"\n")
+	log.Debug("wrote provenance: %s", path)
+	return path
+}
+
+// NewProvenance returns a provenance statement for the files, describing the source commit and the builder: the
+// GitHub Actions workflow run when run in CI, otherwise the local go-make build
+func NewProvenance(subjects []string) Statement {
+	var resources []Resource
+	for _, subject := range subjects {
+		resources = append(resources, Resource{
+			Name:   filepath.Base(subject),
+			Digest: map[string]string{"sha256": file.Sha256Hash(subject)},
+		})
+	}
+
+	commit := lang.Return(git.RevParse("HEAD"))
+	p := github.Payload()
+	serverURL := config.Env("GITHUB_SERVER_URL", "https://github.com")
+
+	source := Resource{
+		URI:    "git+file://" + filepath.ToSlash(lang.Return(filepath.Abs(git.Root()))),
+		Digest: map[string]string{"gitCommit": commit},
+	}
+	if p.Repo != "" {
+		source.URI = fmt.Sprintf("git+%s/%s", serverURL, p.Repo)
+		if p.Ref != "" {
+			source.URI += "@" + p.Ref
+		}
+	}
+
+	external := map[string]string{
+		"source": source.URI,
+	}
+	builder := Builder{ID: localBuilderID}
+	metadata := BuildMetadata{FinishedOn: time.Now().UTC().Format(time.RFC3339)}
+	if config.CI && p.RunID != 0 {
+		external["workflow"] = p.Workflow
+		external["event"] = p.Type
+		external["ref"] = p.Ref
+		builder.ID = "https://github.com/actions/runner/" + config.Env("RUNNER_ENVIRONMENT", "github-hosted")
+		metadata.InvocationID = fmt.Sprintf("%s/%s/actions/runs/%d/attempts/%s", serverURL, p.Repo, p.RunID,
+			lang.Default(os.Getenv("GITHUB_RUN_ATTEMPT"), "1"))
+	}
+
+	return Statement{
+		Type:          statementType,
+		Subject:       resources,
+		PredicateType: predicateType,
+		Predicate: Provenance{
+			BuildDefinition: BuildDefinition{
+				BuildType:          buildType,
+				ExternalParameters: external,
+				InternalParameters: map[string]string{
+					"os":   config.OS,
+					"arch": config.Arch,
+				},
+				ResolvedDependencies: []Resource{source},
+			},
+			RunDetails: RunDetails{
+				Builder:  builder,
+				Metadata: metadata,
+			},
+		},
+	}
+}
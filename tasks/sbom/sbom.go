@@ -0,0 +1,275 @@
+package sbom
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	. "github.com/anchore/go-make"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/gomod"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/log"
+	"github.com/anchore/go-make/run"
+	"github.com/anchore/go-make/tasks/gobuild"
+)
+
+// DefaultFormats are the syft output formats generated when no Formats are specified
+var DefaultFormats = []string{"spdx-json", "cyclonedx-json"}
+
+// formatExtensions are the file extensions of known syft output formats, without any version, e.g. spdx-json@2.2;
+// other formats use the format name
+var formatExtensions = map[string]string{
+	"spdx-json":      ".spdx.json",
+	"spdx-tag-value": ".spdx",
+	"cyclonedx-json": ".cyclonedx.json",
+	"cyclonedx-xml":  ".cyclonedx.xml",
+	"syft-json":      ".syft.json",
+	"github-json":    ".github.json",
+}
+
+func Tasks(options ...Option) Task {
+	cfg := defaultConfig()
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return Task{
+		Name:        cfg.Name,
+		Description: "generate SBOMs and build provenance",
+		Run: func() {
+			Generate(cfg)
+		},
+		Tasks: []Task{
+			{
+				Name:        cfg.Name + ":upload",
+				Description: "generate SBOMs and build provenance, and upload them as a workflow artifact",
+				Run: func() {
+					Upload(cfg, Generate(cfg))
+				},
+			},
+			{
+				Name:   cfg.Name + ":clean",
+				RunsOn: lang.List("clean"),
+				Run: func() {
+					for _, f := range outputs(cfg) {
+						file.Delete(f)
+					}
+				},
+			},
+		},
+	}
+}
+
+type Config struct {
+	// Name is the task name
+	Name string
+	// DistDir is the directory containing built binaries, and the output directory for SBOMs and provenance
+	DistDir string
+	// Formats are the syft output formats, e.g. spdx-json
+	Formats []string
+	// Module generates an SBOM for the module source
+	Module bool
+	// Binaries are glob patterns, relative to the DistDir, of binaries to generate SBOMs for; defaults to the
+	// binaries listed in the gobuild checksums file, or all executables in the DistDir
+	Binaries []string
+	// Provenance writes an in-toto SLSA provenance statement for the binaries and SBOMs, see ProvenanceFile
+	Provenance bool
+	// ArtifactName is the name of the workflow artifact uploaded
+	ArtifactName string
+}
+
+func defaultConfig() Config {
+	return Config{
+		Name:         "sbom",
+		DistDir:      "dist",
+		Formats:      DefaultFormats,
+		Module:       true,
+		Provenance:   true,
+		ArtifactName: "sbom",
+	}
+}
+
+type Option func(*Config)
+
+func Name(name string) Option {
+	return func(c *Config) {
+		c.Name = name
+	}
+}
+
+func DistDir(dir string) Option {
+	return func(c *Config) {
+		c.DistDir = dir
+	}
+}
+
+// Formats sets the syft output formats, e.g. Formats("spdx-json", "syft-json")
+func Formats(formats ...string) Option {
+	return func(c *Config) {
+		c.Formats = formats
+	}
+}
+
+// Binaries sets glob patterns, relative to the DistDir, of binaries to generate SBOMs for, e.g. Binaries("syft_*")
+func Binaries(globs ...string) Option {
+	return func(c *Config) {
+		c.Binaries = append(c.Binaries, globs...)
+	}
+}
+
+// SkipModule does not generate an SBOM for the module source
+func SkipModule() Option {
+	return func(c *Config) {
+		c.Module = false
+	}
+}
+
+// SkipProvenance does not write a provenance statement
+func SkipProvenance() Option {
+	return func(c *Config) {
+		c.Provenance = false
+	}
+}
+
+func ArtifactName(name string) Option {
+	return func(c *Config) {
+		c.ArtifactName = name
+	}
+}
+
+// Generate writes SBOMs in all formats for the module source and each binary to the DistDir using syft, and the
+// provenance statement, returning the files written
+func Generate(cfg Config) []string {
+	file.EnsureDir(cfg.DistDir)
+
+	var written []string
+	if cfg.Module {
+		written = append(written, scan("dir:"+gomod.Root(), filepath.Join(cfg.DistDir, moduleName()+"_source"), cfg.Formats)...)
+	}
+
+	binaries := findBinaries(cfg)
+	for _, binary := range binaries {
+		written = append(written, scan("file:"+binary, binary, cfg.Formats)...)
+	}
+
+	if cfg.Provenance {
+		written = append(written, WriteProvenance(filepath.Join(cfg.DistDir, ProvenanceFile), slices.Concat(binaries, written)))
+	}
+
+	Log("Generated %d SBOM and provenance files in %s", len(written), cfg.DistDir)
+	return written
+}
+
+// Upload uploads the files as a workflow artifact when running in CI
+func Upload(cfg Config, files []string) {
+	if !config.CI {
+		log.Info("Not in CI, skipping upload of: %v", files)
+		return
+	}
+	id := lang.Return(github.NewClient().UploadArtifactDir(cfg.DistDir, uploadOption(cfg, files)))
+	Log("Uploaded artifact %s with id: %v", cfg.ArtifactName, id)
+}
+
+// uploadOption returns the artifact upload options for the files, which are made relative to the DistDir since
+// artifact files are resolved against it
+func uploadOption(cfg Config, files []string) github.UploadArtifactOption {
+	var relative []string
+	for _, f := range files {
+		relative = append(relative, lang.Return(filepath.Rel(cfg.DistDir, f)))
+	}
+	return github.UploadArtifactOption{
+		ArtifactName: cfg.ArtifactName,
+		Overwrite:    true,
+		Files:        relative,
+	}
+}
+
+// scan runs syft for the source, writing each format to the base path with the format extension
+func scan(source, basePath string, formats []string) []string {
+	var args []string
+	var written []string
+	for _, format := range formats {
+		out := basePath + Extension(format)
+		args = append(args, "-o", format+"="+out)
+		written = append(written, out)
+	}
+	Run("syft scan", run.Args(source), run.Args(args...), run.Quiet())
+	return written
+}
+
+// Extension returns the file extension for a syft output format, e.g. .spdx.json for spdx-json
+func Extension(format string) string {
+	if ext, ok := formatExtensions[format]; ok {
+		return ext
+	}
+	if name, _, ok := strings.Cut(format, "@"); ok {
+		return Extension(name)
+	}
+	return "." + format
+}
+
+func findBinaries(cfg Config) []string {
+	var out []string
+	switch {
+	case len(cfg.Binaries) > 0:
+		for _, glob := range cfg.Binaries {
+			out = append(out, lang.Return(filepath.Glob(filepath.Join(cfg.DistDir, glob)))...)
+		}
+	case file.Exists(filepath.Join(cfg.DistDir, gobuild.ChecksumsFile)):
+		// <sha256>  <file name>
+		for _, line := range strings.Split(file.Read(filepath.Join(cfg.DistDir, gobuild.ChecksumsFile)), "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 {
+				out = append(out, filepath.Join(cfg.DistDir, fields[1]))
+			}
+		}
+	default:
+		entries, err := os.ReadDir(cfg.DistDir)
+		if err != nil {
+			return nil
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if info.Mode()&0o111 != 0 || strings.HasSuffix(entry.Name(), ".exe") {
+				out = append(out, filepath.Join(cfg.DistDir, entry.Name()))
+			}
+		}
+	}
+	// exclude previously generated SBOMs and provenance
+	out = lang.Remove(out, func(f string) bool {
+		if filepath.Base(f) == ProvenanceFile {
+			return true
+		}
+		for _, ext := range formatExtensions {
+			if strings.HasSuffix(f, ext) {
+				return true
+			}
+		}
+		return false
+	})
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// outputs returns the SBOM and provenance files in the DistDir
+func outputs(cfg Config) []string {
+	var out []string
+	for _, format := range cfg.Formats {
+		out = append(out, lang.Return(filepath.Glob(filepath.Join(cfg.DistDir, "*"+Extension(format))))...)
+	}
+	return append(out, filepath.Join(cfg.DistDir, ProvenanceFile))
+}
+
+func moduleName() string {
+	if f := gomod.Read(); f != nil && f.Module != nil {
+		return path.Base(f.Module.Mod.Path)
+	}
+	return filepath.Base(file.Cwd())
+}
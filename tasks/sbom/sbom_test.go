@@ -0,0 +1,114 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/github"
+	"github.com/anchore/go-make/require"
+	"github.com/anchore/go-make/tasks/gobuild"
+)
+
+// fakeSyft writes the format and source to each -o format=file output
+const fakeSyft = `#!/bin/sh
+source=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    scan) ;;
+    -o) shift; echo "${1%%=*} $source" > "${1#*=}" ;;
+    *) source="$1" ;;
+  esac
+  shift
+done
+`
+
+func Test_Generate(t *testing.T) {
+	if config.Windows {
+		t.Skip("fake syft is a shell script")
+	}
+	require.SetAndRestore(t, &config.CI, false)
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "syft"), []byte(fakeSyft), 0o700)) //nolint:gosec
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := require.GitRepo(t)
+	require.GitCommit(t, dir, "add module", map[string]string{"go.mod": "module example.com/tools/app\n\ngo 1.21\n"})
+	commit := require.Git(t, dir, "rev-parse", "HEAD")
+
+	file.InDir(dir, func() {
+		file.EnsureDir("dist")
+		require.NoError(t, os.WriteFile(filepath.Join("dist", "app_linux_amd64"), []byte("linux"), 0o700)) //nolint:gosec
+		require.NoError(t, os.WriteFile(filepath.Join("dist", "app_windows_amd64.exe"), []byte("windows"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join("dist", "notes.txt"), []byte("not a binary"), 0o600))
+
+		cfg := defaultConfig()
+		written := Generate(cfg)
+		require.EqualElements(t, []string{
+			filepath.Join("dist", "app_source.spdx.json"),
+			filepath.Join("dist", "app_source.cyclonedx.json"),
+			filepath.Join("dist", "app_linux_amd64.spdx.json"),
+			filepath.Join("dist", "app_linux_amd64.cyclonedx.json"),
+			filepath.Join("dist", "app_windows_amd64.exe.spdx.json"),
+			filepath.Join("dist", "app_windows_amd64.exe.cyclonedx.json"),
+			filepath.Join("dist", ProvenanceFile),
+		}, written)
+		require.Equal(t, "spdx-json dir:"+dir, strings.TrimSpace(file.Read(filepath.Join("dist", "app_source.spdx.json"))))
+		require.Equal(t, "cyclonedx-json file:"+filepath.Join("dist", "app_linux_amd64"),
+			strings.TrimSpace(file.Read(filepath.Join("dist", "app_linux_amd64.cyclonedx.json"))))
+
+		var statement Statement
+		require.NoError(t, json.Unmarshal([]byte(file.Read(filepath.Join("dist", ProvenanceFile))), &statement))
+		require.Equal(t, predicateType, statement.PredicateType)
+		require.Equal(t, 8, len(statement.Subject))
+		require.Equal(t, "app_linux_amd64", statement.Subject[0].Name)
+		require.Equal(t, file.Sha256Hash(filepath.Join("dist", "app_linux_amd64")), statement.Subject[0].Digest["sha256"])
+		require.Equal(t, commit, statement.Predicate.BuildDefinition.ResolvedDependencies[0].Digest["gitCommit"])
+		require.Equal(t, localBuilderID, statement.Predicate.RunDetails.Builder.ID)
+
+		// uploaded files resolve to the generated files in the dist directory
+		var expected []string
+		for _, f := range written {
+			expected = append(expected, filepath.Join(dir, f))
+		}
+		require.EqualElements(t, expected, github.ArtifactFiles(cfg.DistDir, uploadOption(cfg, written)))
+
+		// binaries listed in the gobuild checksums file
+		file.Write(filepath.Join("dist", gobuild.ChecksumsFile), "abc  app_windows_amd64.exe\n")
+		cfg = defaultConfig()
+		SkipModule()(&cfg)
+		SkipProvenance()(&cfg)
+		Formats("syft-json", "spdx-json@2.2")(&cfg)
+		require.EqualElements(t, []string{
+			filepath.Join("dist", "app_windows_amd64.exe.syft.json"),
+			filepath.Join("dist", "app_windows_amd64.exe.spdx.json"),
+		}, Generate(cfg))
+
+		require.Equal(t, []string{filepath.Join("dist", "app_linux_amd64")}, findBinaries(Config{DistDir: "dist", Binaries: []string{"*_linux_*"}}))
+	})
+}
+
+func Test_NewProvenanceCI(t *testing.T) {
+	dir := require.GitRepo(t)
+	require.SetAndRestore(t, &config.CI, true)
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_REF", "refs/tags/v1.0.0")
+	t.Setenv("GITHUB_WORKFLOW", "Release")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "2")
+
+	file.InDir(dir, func() {
+		statement := NewProvenance([]string{"README.md"})
+		require.Equal(t, "https://github.example/owner/repo/actions/runs/42/attempts/2", statement.Predicate.RunDetails.Metadata.InvocationID)
+		require.Equal(t, "git+https://github.example/owner/repo@refs/tags/v1.0.0", statement.Predicate.BuildDefinition.ResolvedDependencies[0].URI)
+		require.Equal(t, "Release", statement.Predicate.BuildDefinition.ExternalParameters["workflow"])
+		require.Equal(t, "README.md", statement.Subject[0].Name)
+		require.True(t, statement.Predicate.RunDetails.Builder.ID != localBuilderID)
+	})
+}
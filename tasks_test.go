@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/anchore/go-make/changes"
+	"github.com/anchore/go-make/config"
+	"github.com/anchore/go-make/lang"
 	"github.com/anchore/go-make/require"
 	"github.com/anchore/go-make/run"
 )
@@ -20,3 +23,47 @@ func Test_errorsIncludeStackTrace(t *testing.T) {
 	// includes a link to the file:line in the script where the error occurred -- IMPORTANT!
 	require.Contains(t, stderr.String(), "main.go:20")
 }
+
+func Test_pathFilteredTasks(t *testing.T) {
+	require.SetAndRestore(t, &config.ChangedOnly, true)
+	require.SetAndRestore(t, &changes.Files, func() ([]string, error) {
+		return []string{"docs/guide.md"}, nil
+	})
+
+	var ran []string
+	task := func(name string, paths ...string) Task {
+		return Task{
+			Name:  name,
+			Paths: paths,
+			Run: func() {
+				ran = append(ran, name)
+			},
+		}
+	}
+
+	r := taskRunner{}
+	r.addTasks(
+		task("docs", "docs/**"),
+		task("lint", "**/*.go").DependsOn("format"),
+		task("format"),
+		task("always"),
+		task("static-analysis", "**/*.go"),
+	)
+	attached := func(name string, paths ...string) Task {
+		tsk := task(name, paths...)
+		tsk.RunsOn = lang.List("static-analysis")
+		return tsk
+	}
+	r.addTasks(
+		attached("go-lint", "**/*.go"),
+		attached("docs-lint", "docs/**"),
+		attached("hygiene"),
+	)
+	r.Run("docs", "lint", "always")
+	require.Equal(t, []string{"docs", "always"}, ran)
+
+	// tasks attached to a skipped task are run, subject to their own paths
+	ran = nil
+	r.Run("static-analysis")
+	require.Equal(t, []string{"docs-lint", "hygiene"}, ran)
+}
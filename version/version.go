@@ -0,0 +1,181 @@
+package version
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/run"
+)
+
+// DefaultVariable is the variable set by LDFlags when none is specified
+const DefaultVariable = "main.version"
+
+// tagMatch limits git describe to version tags
+const tagMatch = "v[0-9]*"
+
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+// Semver is a parsed semantic version
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Metadata   string
+}
+
+// Parse parses a semantic version, with or without a v prefix
+func Parse(version string) (Semver, error) {
+	parts := semverPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if parts == nil {
+		return Semver{}, fmt.Errorf("invalid semantic version: %q", version)
+	}
+	return Semver{
+		Major:      lang.Return(strconv.Atoi(parts[1])),
+		Minor:      lang.Return(strconv.Atoi(parts[2])),
+		Patch:      lang.Return(strconv.Atoi(parts[3])),
+		Prerelease: parts[4],
+		Metadata:   parts[5],
+	}, nil
+}
+
+// String returns the version with a v prefix, e.g. v1.2.3-rc.1+abc
+func (s Semver) String() string {
+	out := fmt.Sprintf("v%d.%d.%d", s.Major, s.Minor, s.Patch)
+	if s.Prerelease != "" {
+		out += "-" + s.Prerelease
+	}
+	if s.Metadata != "" {
+		out += "+" + s.Metadata
+	}
+	return out
+}
+
+// Core returns the version without pre-release or metadata
+func (s Semver) Core() Semver {
+	return Semver{Major: s.Major, Minor: s.Minor, Patch: s.Patch}
+}
+
+// NextMajor returns the next major release version
+func (s Semver) NextMajor() Semver {
+	return Semver{Major: s.Major + 1}
+}
+
+// NextMinor returns the next minor release version
+func (s Semver) NextMinor() Semver {
+	return Semver{Major: s.Major, Minor: s.Minor + 1}
+}
+
+// NextPatch returns the next patch release version; for a pre-release, this is the release it precedes
+func (s Semver) NextPatch() Semver {
+	if s.Prerelease != "" {
+		return s.Core()
+	}
+	return Semver{Major: s.Major, Minor: s.Minor, Patch: s.Patch + 1}
+}
+
+// Current returns the version of the current checkout: the version tag at HEAD when it is clean, otherwise a
+// pre-release of the next patch version, e.g. v1.2.4-dev.5+g1a2b3c4 for 5 commits after v1.2.3, or extending a
+// pre-release tag, e.g. v1.2.3-rc.1.dev.5+g1a2b3c4 for 5 commits after v1.2.3-rc.1, with a .dirty suffix when there
+// are uncommitted changes
+func Current() string {
+	return lang.Return(Compute()).String()
+}
+
+// Tag returns the version tag at HEAD, if there is one
+func Tag() (string, bool) {
+	return tagAt("HEAD")
+}
+
+// tagAt returns the version tag at the ref, preferring a release over a pre-release, e.g. when v1.2.3 and v1.2.3-rc.1
+// tag the same commit
+func tagAt(ref string) (string, bool) {
+	tags, err := git.Tags(ref)
+	if err != nil {
+		return "", false
+	}
+	var found []Semver
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "v") {
+			continue
+		}
+		if v, err := Parse(tag); err == nil {
+			found = append(found, v)
+		}
+	}
+	if len(found) == 0 {
+		return "", false
+	}
+	for _, v := range found {
+		if v.Prerelease == "" {
+			return v.String(), true
+		}
+	}
+	return found[0].String(), true
+}
+
+// Compute returns the Semver for the current checkout, see Current
+func Compute() (Semver, error) {
+	d, err := git.Describe(tagMatch)
+	if err != nil {
+		return Semver{}, err
+	}
+	if !d.Dirty {
+		if tag, ok := Tag(); ok {
+			return Parse(tag)
+		}
+	}
+
+	base := Semver{}
+	distance := d.Distance
+	if d.Tag != "" {
+		tag, _ := tagAt(d.Tag + "^{commit}")
+		if base, err = Parse(lang.Default(tag, d.Tag)); err != nil {
+			return Semver{}, err
+		}
+	} else if distance, err = git.CommitCount("HEAD"); err != nil {
+		return Semver{}, err
+	}
+
+	// after a pre-release, the dev version extends the pre-release so it sorts after it, e.g. v1.2.3-rc.1.dev.5
+	next := base.NextPatch()
+	next.Prerelease = fmt.Sprintf("dev.%d", distance)
+	if base.Prerelease != "" {
+		next.Prerelease = base.Prerelease + "." + next.Prerelease
+	}
+	next.Metadata = "g" + d.Hash
+	if d.Dirty {
+		next.Metadata += ".dirty"
+	}
+	return next, nil
+}
+
+// LDFlag returns the linker flag to set the variable to the Current version, e.g. -X main.version=v1.2.3
+func LDFlag(variable string) string {
+	return fmt.Sprintf("-X %s=%s", variable, Current())
+}
+
+// LDFlags is a run.Option adding -ldflags to set each variable to the Current version, or DefaultVariable if none
+// are provided, e.g. Run("go build", version.LDFlags()); the version is determined when the option is applied
+func LDFlags(variables ...string) run.Option {
+	if len(variables) == 0 {
+		variables = []string{DefaultVariable}
+	}
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		v, err := Compute()
+		if err != nil {
+			return err
+		}
+		var flags []string
+		for _, variable := range variables {
+			flags = append(flags, fmt.Sprintf("-X %s=%s", variable, v))
+		}
+		return run.LDFlags(flags...)(ctx, cmd)
+	}
+}
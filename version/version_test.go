@@ -0,0 +1,112 @@
+package version
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/anchore/go-make/file"
+	"github.com/anchore/go-make/git"
+	"github.com/anchore/go-make/lang"
+	"github.com/anchore/go-make/require"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Semver
+		wantErr  require.ValidationError
+	}{
+		{
+			input:    "v1.2.3",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3},
+		},
+		{
+			input:    "1.2.3-rc.1+build.5",
+			expected: Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Metadata: "build.5"},
+		},
+		{
+			input:   "v1.2",
+			wantErr: require.Error,
+		},
+		{
+			input:   "latest",
+			wantErr: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := Parse(tt.input)
+			tt.wantErr.Validate(t, err)
+			if err == nil {
+				require.Equal(t, tt.expected, v)
+				require.Equal(t, "v"+strings.TrimPrefix(tt.input, "v"), v.String())
+			}
+		})
+	}
+}
+
+func Test_Next(t *testing.T) {
+	v := lang.Return(Parse("v1.2.3"))
+	require.Equal(t, "v2.0.0", v.NextMajor().String())
+	require.Equal(t, "v1.3.0", v.NextMinor().String())
+	require.Equal(t, "v1.2.4", v.NextPatch().String())
+	require.Equal(t, "v1.2.3", lang.Return(Parse("v1.2.3-rc.1")).NextPatch().String())
+}
+
+func Test_Current(t *testing.T) {
+	dir := require.GitRepo(t)
+
+	file.InDir(dir, func() {
+		hash := git.Revision()
+		require.Equal(t, "v0.0.1-dev.1+g"+hash, Current())
+
+		require.Git(t, dir, "tag", "v1.2.3")
+		require.Equal(t, "v1.2.3", Current())
+
+		require.NoError(t, os.WriteFile("README.md", []byte("changed"), 0o600))
+		require.Equal(t, "v1.2.4-dev.0+g"+hash+".dirty", Current())
+
+		require.GitCommit(t, dir, "one", map[string]string{"README.md": "one"})
+		require.GitCommit(t, dir, "two", nil)
+		require.Equal(t, "v1.2.4-dev.2+g"+git.Revision(), Current())
+
+		// non-version tags are ignored
+		require.Git(t, dir, "tag", "latest")
+		require.Equal(t, "v1.2.4-dev.2+g"+git.Revision(), Current())
+		_, ok := Tag()
+		require.True(t, !ok)
+
+		require.Git(t, dir, "tag", "v1.3.0-rc.1")
+		require.Git(t, dir, "tag", "v1.3.0")
+		tag, ok := Tag()
+		require.True(t, ok)
+		require.Equal(t, "v1.3.0", tag)
+
+		require.Equal(t, "-X main.version=v1.3.0", LDFlag(DefaultVariable))
+
+		// the release is preferred over a pre-release tagging the same commit
+		require.GitCommit(t, dir, "three", nil)
+		require.Equal(t, "v1.3.1-dev.1+g"+git.Revision(), Current())
+
+		// dev versions after a pre-release sort after it
+		require.Git(t, dir, "tag", "-a", "v1.4.0-rc.1", "-m", "rc")
+		require.GitCommit(t, dir, "four", nil)
+		require.Equal(t, "v1.4.0-rc.1.dev.1+g"+git.Revision(), Current())
+
+		cmd := exec.Command("go", "build")
+		require.NoError(t, LDFlags("main.version", "main.other")(context.Background(), cmd))
+		require.Contains(t, strings.Join(cmd.Args, " "), "-X main.version=v1.4.0-rc.1.dev.1+g"+git.Revision())
+		require.Contains(t, strings.Join(cmd.Args, " "), "-X main.other=v1.4.0-rc.1.dev.1+g"+git.Revision())
+	})
+}
+
+func Test_LDFlagsOutsideRepository(t *testing.T) {
+	file.InDir(t.TempDir(), func() {
+		// the version is only determined when the option is applied
+		opt := LDFlags()
+		require.Error(t, opt(context.Background(), exec.Command("go", "build")))
+	})
+}